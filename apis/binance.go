@@ -0,0 +1,101 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sljivkov/dectek/config"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// defaultBinanceInterval is used when no polling interval is configured
+const defaultBinanceInterval = 10 * time.Second
+
+// binancePairs maps our token identifiers to Binance USDT trading pairs
+var binancePairs = map[string]string{
+	"bitcoin":     "BTCUSDT",
+	"ethereum":    "ETHUSDT",
+	"binancecoin": "BNBUSDT",
+	"solana":      "SOLUSDT",
+	"ripple":      "XRPUSDT",
+	"cardano":     "ADAUSDT",
+	"dogecoin":    "DOGEUSDT",
+	"polkadot":    "DOTUSDT",
+	"chainlink":   "LINKUSDT",
+	"litecoin":    "LTCUSDT",
+	"tron":        "TRXUSDT",
+	"avalanche-2": "AVAXUSDT",
+}
+
+// Binance implements a price feed using the Binance public ticker API
+type Binance struct {
+	httpProvider
+	cfg config.Config
+}
+
+// BinanceTicker represents a single entry of the Binance ticker price response
+type BinanceTicker struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+// NewBinance creates a new Binance price feed instance
+func NewBinance(cfg config.Config) *Binance {
+	return &Binance{
+		httpProvider: newHTTPProvider("Binance",
+			durationOrDefault(cfg.BinanceInterval, defaultBinanceInterval), binancePairs, cfg.BinancePairs),
+		cfg: cfg,
+	}
+}
+
+// getPrices fetches current prices from the Binance ticker API
+func (b *Binance) getPrices(ctx context.Context) ([]pricefeed.Price, error) {
+	tokens, symbols, err := b.exchangeSymbols(b.cfg.Tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode symbols: %w", err)
+	}
+
+	params := url.Values{}
+	params.Add("symbols", string(encoded))
+
+	var raw []BinanceTicker
+	if err := b.getJSON(ctx, b.cfg.BinanceUrl, params, &raw); err != nil {
+		return nil, err
+	}
+
+	prices := make([]pricefeed.Price, 0, len(raw))
+
+	for _, ticker := range raw {
+		token, ok := tokens[ticker.Symbol]
+		if !ok {
+			continue
+		}
+
+		usd, err := pricefeed.ParseDecimal(ticker.Price)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s price %q: %w", ticker.Symbol, ticker.Price, err)
+		}
+
+		prices = append(prices, b.setPrice(token, usd))
+	}
+
+	return prices, nil
+}
+
+// FetchPrices fetches current prices once, for use as a reference price
+func (b *Binance) FetchPrices(ctx context.Context) ([]pricefeed.Price, error) {
+	return b.getPrices(ctx)
+}
+
+// UpdatePriceFromApi continuously updates prices from the Binance API
+func (b *Binance) UpdatePriceFromApi(ctx context.Context, priceCh chan<- []pricefeed.Price) {
+	b.poll(ctx, priceCh, b.getPrices)
+}
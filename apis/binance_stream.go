@@ -0,0 +1,248 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sljivkov/dectek/config"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+const (
+	// defaultStreamDebounce is used when no debounce window is configured
+	defaultStreamDebounce = 2 * time.Second
+	// defaultStreamMaxBackoff caps the delay between reconnect attempts
+	defaultStreamMaxBackoff = time.Minute
+	// streamMinBackoff is the delay before the first reconnect attempt
+	streamMinBackoff = time.Second
+	// streamReadTimeout drops connections that stay silent for too long
+	streamReadTimeout = 5 * time.Minute
+)
+
+// BinanceStream implements a streaming price feed using Binance mini ticker websockets
+type BinanceStream struct {
+	cfg        config.Config
+	pairs      map[string]string // token identifier -> trading pair
+	dialer     *websocket.Dialer
+	debounce   time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// BinanceStreamMessage represents a combined stream mini ticker message from Binance
+type BinanceStreamMessage struct {
+	Stream string `json:"stream"`
+	Data   struct {
+		Symbol string `json:"s"`
+		Close  string `json:"c"`
+	} `json:"data"`
+}
+
+// NewBinanceStream creates a new Binance websocket price feed instance
+func NewBinanceStream(cfg config.Config) *BinanceStream {
+	return &BinanceStream{
+		cfg:   cfg,
+		pairs: mergeSymbols(binancePairs, cfg.BinancePairs),
+		dialer: &websocket.Dialer{
+			HandshakeTimeout: defaultHTTPTimeout,
+		},
+		debounce:   durationOrDefault(cfg.StreamDebounce, defaultStreamDebounce),
+		minBackoff: streamMinBackoff,
+		maxBackoff: durationOrDefault(cfg.StreamMaxBackoff, defaultStreamMaxBackoff),
+	}
+}
+
+// streamURL builds the combined stream URL and a reverse lookup from trading pair to token
+func (s *BinanceStream) streamURL() (string, map[string]string, error) {
+	tokens := make(map[string]string)
+	streams := make([]string, 0)
+
+	for _, token := range strings.Split(s.cfg.Tokens, ",") {
+		token = strings.TrimSpace(token)
+
+		pair, ok := s.pairs[token]
+		if !ok {
+			log.Printf("⚠️ No Binance trading pair for %s", token)
+
+			continue
+		}
+
+		tokens[pair] = token
+		streams = append(streams, strings.ToLower(pair)+"@miniTicker")
+	}
+
+	if len(streams) == 0 {
+		return "", nil, fmt.Errorf("no configured tokens have a Binance trading pair")
+	}
+
+	return fmt.Sprintf("%s?streams=%s", s.cfg.BinanceStreamUrl, strings.Join(streams, "/")), tokens, nil
+}
+
+// UpdatePriceFromApi streams prices from Binance, reconnecting with exponential backoff
+// until ctx is canceled
+func (s *BinanceStream) UpdatePriceFromApi(ctx context.Context, priceCh chan<- []pricefeed.Price) {
+	log.Println("📡 Starting Binance stream price service")
+
+	defer close(priceCh)
+
+	backoff := s.minBackoff
+
+	for {
+		received, err := s.stream(ctx, priceCh)
+		if ctx.Err() != nil {
+			log.Println("🛑 Stopping Binance stream price service")
+
+			return
+		}
+
+		if received {
+			backoff = s.minBackoff
+		}
+
+		log.Printf("🔌 Binance stream disconnected: %v, reconnecting in %s", err, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			log.Println("🛑 Stopping Binance stream price service")
+
+			return
+		}
+
+		backoff = min(backoff*2, s.maxBackoff)
+	}
+}
+
+// stream runs a single websocket session, sending coalesced prices every debounce window.
+// It reports whether any price was received before the session ended.
+func (s *BinanceStream) stream(ctx context.Context, priceCh chan<- []pricefeed.Price) (bool, error) {
+	streamURL, tokens, err := s.streamURL()
+	if err != nil {
+		return false, err
+	}
+
+	conn, _, err := s.dialer.DialContext(ctx, streamURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	defer conn.Close()
+
+	log.Printf("✅ Connected to Binance stream for %d pairs", len(tokens))
+
+	var (
+		ticks = make(chan pricefeed.Price)
+		errCh = make(chan error, 1)
+		done  = make(chan struct{})
+	)
+
+	defer close(done)
+
+	go s.read(conn, tokens, ticks, errCh, done)
+
+	ticker := time.NewTicker(s.debounce)
+	defer ticker.Stop()
+
+	pending := make(map[string]pricefeed.Decimal)
+	received := false
+
+	for {
+		select {
+		case tick := <-ticks:
+			pending[tick.Symbol] = tick.USD
+			received = true
+		case <-ticker.C:
+			if len(pending) == 0 {
+				continue
+			}
+
+			select {
+			case priceCh <- coalesce(pending):
+			case <-ctx.Done():
+				return received, ctx.Err()
+			}
+
+			pending = make(map[string]pricefeed.Decimal)
+		case err := <-errCh:
+			if len(pending) > 0 {
+				select {
+				case priceCh <- coalesce(pending):
+				case <-ctx.Done():
+				}
+			}
+
+			return received, err
+		case <-ctx.Done():
+			return received, ctx.Err()
+		}
+	}
+}
+
+// read decodes ticker messages from conn until it fails or done is closed
+func (s *BinanceStream) read(
+	conn *websocket.Conn,
+	tokens map[string]string,
+	ticks chan<- pricefeed.Price,
+	errCh chan<- error,
+	done <-chan struct{},
+) {
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(streamReadTimeout)); err != nil {
+			errCh <- err
+
+			return
+		}
+
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			errCh <- err
+
+			return
+		}
+
+		var msg BinanceStreamMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			log.Printf("⚠️ Failed to decode Binance stream message: %v", err)
+
+			continue
+		}
+
+		token, ok := tokens[msg.Data.Symbol]
+		if !ok {
+			continue
+		}
+
+		usd, err := pricefeed.ParseDecimal(msg.Data.Close)
+		if err != nil {
+			log.Printf("⚠️ Failed to parse %s price %q: %v", msg.Data.Symbol, msg.Data.Close, err)
+
+			continue
+		}
+
+		select {
+		case ticks <- pricefeed.Price{Symbol: token, USD: usd}:
+		case <-done:
+			return
+		}
+	}
+}
+
+// coalesce converts the latest price per token into a batch
+func coalesce(pending map[string]pricefeed.Decimal) []pricefeed.Price {
+	prices := make([]pricefeed.Price, 0, len(pending))
+
+	for symbol, usd := range pending {
+		prices = append(prices, pricefeed.Price{
+			Symbol: symbol,
+			USD:    usd,
+		})
+	}
+
+	return prices
+}
@@ -0,0 +1,148 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/config"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// newStreamServer starts a local websocket server that runs session for every connection
+func newStreamServer(t *testing.T, session func(conn *websocket.Conn, n int)) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	var connections atomic.Int32
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "btcusdt@miniTicker/ethusdt@miniTicker", r.URL.Query().Get("streams"))
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+
+			return
+		}
+
+		defer conn.Close()
+
+		session(conn, int(connections.Add(1)))
+	}))
+}
+
+func tickerMessage(symbol, price string) []byte {
+	return []byte(`{"stream":"` + strings.ToLower(symbol) + `@miniTicker","data":{"s":"` + symbol + `","c":"` + price + `"}}`)
+}
+
+func newTestBinanceStream(serverURL string) *BinanceStream {
+	stream := NewBinanceStream(config.Config{
+		Tokens:           "bitcoin,ethereum",
+		BinanceStreamUrl: "ws" + strings.TrimPrefix(serverURL, "http"),
+		StreamDebounce:   100 * time.Millisecond,
+	})
+	stream.minBackoff = 10 * time.Millisecond
+
+	return stream
+}
+
+func TestNewBinanceStream(t *testing.T) {
+	stream := NewBinanceStream(config.Config{})
+	assert.NotNil(t, stream)
+	assert.Equal(t, "BTCUSDT", stream.pairs["bitcoin"])
+	assert.Equal(t, defaultStreamDebounce, stream.debounce)
+	assert.Equal(t, defaultStreamMaxBackoff, stream.maxBackoff)
+}
+
+func TestBinanceStreamCoalescesUpdates(t *testing.T) {
+	server := newStreamServer(t, func(conn *websocket.Conn, _ int) {
+		conn.WriteMessage(websocket.TextMessage, tickerMessage("BTCUSDT", "30000.00"))
+		conn.WriteMessage(websocket.TextMessage, tickerMessage("BTCUSDT", "30100.00"))
+		conn.WriteMessage(websocket.TextMessage, tickerMessage("ETHUSDT", "2000.00"))
+
+		// Keep the connection open until the client goes away
+		conn.ReadMessage()
+	})
+	defer server.Close()
+
+	stream := newTestBinanceStream(server.URL)
+	priceCh := make(chan []pricefeed.Price, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go stream.UpdatePriceFromApi(ctx, priceCh)
+
+	select {
+	case prices := <-priceCh:
+		got := make(map[string]string)
+		for _, price := range prices {
+			got[price.Symbol] = price.USD.String()
+		}
+
+		assert.Equal(t, map[string]string{"bitcoin": "30100", "ethereum": "2000"}, got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for streamed prices")
+	}
+}
+
+func TestBinanceStreamReconnects(t *testing.T) {
+	server := newStreamServer(t, func(conn *websocket.Conn, n int) {
+		if n == 1 {
+			// Drop the first connection without sending anything
+			return
+		}
+
+		conn.WriteMessage(websocket.TextMessage, tickerMessage("ETHUSDT", "2000.00"))
+		conn.ReadMessage()
+	})
+	defer server.Close()
+
+	stream := newTestBinanceStream(server.URL)
+	priceCh := make(chan []pricefeed.Price, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go stream.UpdatePriceFromApi(ctx, priceCh)
+
+	select {
+	case prices := <-priceCh:
+		assert.Len(t, prices, 1)
+		assert.Equal(t, "ethereum", prices[0].Symbol)
+		assert.Equal(t, "2000", prices[0].USD.String())
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for streamed prices after reconnect")
+	}
+}
+
+func TestBinanceStreamStopsOnCancel(t *testing.T) {
+	server := newStreamServer(t, func(conn *websocket.Conn, _ int) {
+		conn.ReadMessage()
+	})
+	defer server.Close()
+
+	stream := newTestBinanceStream(server.URL)
+	priceCh := make(chan []pricefeed.Price)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go stream.UpdatePriceFromApi(ctx, priceCh)
+
+	cancel()
+
+	select {
+	case _, ok := <-priceCh:
+		assert.False(t, ok, "channel should be closed after cancellation")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for stream to stop")
+	}
+}
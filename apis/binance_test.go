@@ -0,0 +1,128 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/config"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+func TestNewBinance(t *testing.T) {
+	cfg := config.Config{
+		Tokens:       "bitcoin,ethereum",
+		BinanceUrl:   "http://test.com",
+		BinancePairs: map[string]string{"pepe": "pepeusdt"},
+	}
+
+	binance := NewBinance(cfg)
+	assert.NotNil(t, binance)
+	assert.Equal(t, cfg, binance.cfg)
+	assert.NotNil(t, binance.apiPrices)
+	assert.Equal(t, "BTCUSDT", binance.symbols["bitcoin"])
+	assert.Equal(t, "PEPEUSDT", binance.symbols["pepe"])
+	assert.Equal(t, defaultBinanceInterval, binance.interval)
+}
+
+func TestBinanceGetPrices(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify query parameters
+		assert.Equal(t, `["BTCUSDT","ETHUSDT"]`, r.URL.Query().Get("symbols"))
+
+		// Return mock response
+		response := []BinanceTicker{
+			{Symbol: "BTCUSDT", Price: "30000.00000000"},
+			{Symbol: "ETHUSDT", Price: "2000.00000000"},
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	cfg := config.Config{
+		Tokens:     "bitcoin,ethereum,unknown",
+		BinanceUrl: server.URL,
+	}
+
+	binance := NewBinance(cfg)
+	prices, err := binance.getPrices(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, prices, 2)
+
+	// Verify prices
+	expectedPrices := map[string]string{
+		"bitcoin":  "30000",
+		"ethereum": "2000",
+	}
+
+	for _, price := range prices {
+		assert.Equal(t, expectedPrices[price.Symbol], price.USD.String())
+	}
+
+	for token, usd := range binance.ApiPrices() {
+		assert.Equal(t, expectedPrices[token], usd.String())
+	}
+}
+
+func TestBinanceGetPrices_Errors(t *testing.T) {
+	t.Run("non-200 status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		binance := NewBinance(config.Config{Tokens: "bitcoin", BinanceUrl: server.URL})
+		_, err := binance.getPrices(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("no mapped tokens", func(t *testing.T) {
+		binance := NewBinance(config.Config{Tokens: "unknown", BinanceUrl: "http://test.com"})
+		_, err := binance.getPrices(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+func TestBinanceUpdatePriceFromApi(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		response := []BinanceTicker{
+			{Symbol: "BTCUSDT", Price: "30000.00"},
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	cfg := config.Config{
+		Tokens:     "bitcoin",
+		BinanceUrl: server.URL,
+	}
+
+	binance := NewBinance(cfg)
+	priceCh := make(chan []pricefeed.Price, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start UpdatePriceFromApi in a goroutine
+	go func() {
+		binance.UpdatePriceFromApi(ctx, priceCh)
+	}()
+
+	// Wait for the first price update
+	select {
+	case prices := <-priceCh:
+		assert.Len(t, prices, 1)
+		assert.Equal(t, "bitcoin", prices[0].Symbol)
+		assert.Equal(t, "30000", prices[0].USD.String())
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for price update")
+	}
+}
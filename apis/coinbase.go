@@ -0,0 +1,89 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sljivkov/dectek/config"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// defaultCoinbaseInterval is used when no polling interval is configured
+const defaultCoinbaseInterval = 10 * time.Second
+
+// coinbasePairs maps our token identifiers to Coinbase USD currency pairs
+var coinbasePairs = map[string]string{
+	"bitcoin":     "BTC-USD",
+	"ethereum":    "ETH-USD",
+	"solana":      "SOL-USD",
+	"ripple":      "XRP-USD",
+	"cardano":     "ADA-USD",
+	"dogecoin":    "DOGE-USD",
+	"polkadot":    "DOT-USD",
+	"chainlink":   "LINK-USD",
+	"litecoin":    "LTC-USD",
+	"avalanche-2": "AVAX-USD",
+}
+
+// Coinbase implements a price feed using the Coinbase public spot price API
+type Coinbase struct {
+	httpProvider
+	cfg config.Config
+}
+
+// CoinbaseSpot represents the spot price response structure from Coinbase
+type CoinbaseSpot struct {
+	Data struct {
+		Amount   string `json:"amount"`
+		Base     string `json:"base"`
+		Currency string `json:"currency"`
+	} `json:"data"`
+}
+
+// NewCoinbase creates a new Coinbase price feed instance
+func NewCoinbase(cfg config.Config) *Coinbase {
+	return &Coinbase{
+		httpProvider: newHTTPProvider("Coinbase",
+			durationOrDefault(cfg.CoinbaseInterval, defaultCoinbaseInterval), coinbasePairs, cfg.CoinbasePairs),
+		cfg: cfg,
+	}
+}
+
+// getPrices fetches current prices from the Coinbase spot price API, one request per pair
+func (c *Coinbase) getPrices(ctx context.Context) ([]pricefeed.Price, error) {
+	tokens, pairs, err := c.exchangeSymbols(c.cfg.Tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make([]pricefeed.Price, 0, len(pairs))
+
+	for _, pair := range pairs {
+		var spot CoinbaseSpot
+
+		endpoint := fmt.Sprintf("%s/%s/spot", c.cfg.CoinbaseUrl, pair)
+		if err := c.getJSON(ctx, endpoint, nil, &spot); err != nil {
+			return nil, fmt.Errorf("%s: %w", pair, err)
+		}
+
+		usd, err := pricefeed.ParseDecimal(spot.Data.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s price %q: %w", pair, spot.Data.Amount, err)
+		}
+
+		prices = append(prices, c.setPrice(tokens[pair], usd))
+	}
+
+	return prices, nil
+}
+
+// FetchPrices fetches current prices once, for use as a reference price
+func (c *Coinbase) FetchPrices(ctx context.Context) ([]pricefeed.Price, error) {
+	return c.getPrices(ctx)
+}
+
+// UpdatePriceFromApi continuously updates prices from the Coinbase API
+func (c *Coinbase) UpdatePriceFromApi(ctx context.Context, priceCh chan<- []pricefeed.Price) {
+	c.poll(ctx, priceCh, c.getPrices)
+}
@@ -0,0 +1,82 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/config"
+)
+
+func TestNewCoinbase(t *testing.T) {
+	cfg := config.Config{
+		Tokens:      "bitcoin",
+		CoinbaseUrl: "http://test.com",
+	}
+
+	coinbase := NewCoinbase(cfg)
+	assert.NotNil(t, coinbase)
+	assert.Equal(t, cfg, coinbase.cfg)
+	assert.Equal(t, "BTC-USD", coinbase.symbols["bitcoin"])
+	assert.Equal(t, defaultCoinbaseInterval, coinbase.interval)
+}
+
+func TestCoinbaseGetPrices(t *testing.T) {
+	amounts := map[string]string{
+		"BTC-USD": "30000.00",
+		"ETH-USD": "2000.00",
+	}
+
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pair := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/spot")
+
+		amount, ok := amounts[pair]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		var response CoinbaseSpot
+		response.Data.Amount = amount
+		response.Data.Currency = "USD"
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	coinbase := NewCoinbase(config.Config{
+		Tokens:      "bitcoin,ethereum",
+		CoinbaseUrl: server.URL,
+	})
+
+	prices, err := coinbase.getPrices(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, prices, 2)
+
+	// Verify prices
+	expectedPrices := map[string]string{
+		"bitcoin":  "30000",
+		"ethereum": "2000",
+	}
+
+	for _, price := range prices {
+		assert.Equal(t, expectedPrices[price.Symbol], price.USD.String())
+	}
+}
+
+func TestCoinbaseGetPrices_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	coinbase := NewCoinbase(config.Config{Tokens: "bitcoin", CoinbaseUrl: server.URL})
+	_, err := coinbase.getPrices(context.Background())
+	assert.Error(t, err)
+}
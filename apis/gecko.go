@@ -3,10 +3,6 @@ package apis
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
 	"net/url"
 	"time"
 
@@ -14,93 +10,56 @@ import (
 	"github.com/sljivkov/dectek/pricefeed"
 )
 
+// geckoInterval respects CoinGecko's public API rate limit
+const geckoInterval = 61 * time.Second
+
 // CoinGecko implements a price feed using the CoinGecko API
 type CoinGecko struct {
-	cfg       config.Config
-	apiPrices map[string]float64
-	client    *http.Client
+	httpProvider
+	cfg config.Config
 }
 
 // CurrencyPrice represents the price response structure from CoinGecko
 type CurrencyPrice struct {
-	USD float64 `json:"usd"`
+	USD pricefeed.Decimal `json:"usd"`
 }
 
 // NewCoinGecko creates a new CoinGecko price feed instance
 func NewCoinGecko(cfg config.Config) *CoinGecko {
 	return &CoinGecko{
-		cfg:       cfg,
-		apiPrices: make(map[string]float64),
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		httpProvider: newHTTPProvider("CoinGecko", geckoInterval),
+		cfg:          cfg,
 	}
 }
 
 // getPrices fetches current prices from the CoinGecko API
-func (g *CoinGecko) getPrices() ([]pricefeed.Price, error) {
+func (g *CoinGecko) getPrices(ctx context.Context) ([]pricefeed.Price, error) {
 	params := url.Values{}
 	params.Add("ids", g.cfg.Tokens)
 	params.Add("vs_currencies", "usd")
 	params.Add("precision", g.cfg.Precision)
 	params.Add("include_last_update_at", "true")
 
-	fullURL := fmt.Sprintf("%s?%s", g.cfg.Url, params.Encode())
-
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, fullURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := g.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch prices: %w", err)
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned non-200 status: %d", resp.StatusCode)
-	}
-
 	var raw map[string]CurrencyPrice
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := g.getJSON(ctx, g.cfg.Url, params, &raw); err != nil {
+		return nil, err
 	}
 
 	prices := make([]pricefeed.Price, 0, len(raw))
 
 	for symbol, data := range raw {
-		g.apiPrices[symbol] = data.USD
-		prices = append(prices, pricefeed.Price{
-			Symbol: symbol,
-			USD:    data.USD,
-		})
+		prices = append(prices, g.setPrice(symbol, data.USD))
 	}
 
 	return prices, nil
 }
 
-// ApiPrices returns the current cached API prices
-func (g *CoinGecko) ApiPrices() map[string]float64 {
-	return g.apiPrices
+// FetchPrices fetches current prices once, for use as a reference price
+func (g *CoinGecko) FetchPrices(ctx context.Context) ([]pricefeed.Price, error) {
+	return g.getPrices(ctx)
 }
 
 // UpdatePriceFromApi continuously updates prices from the CoinGecko API
-func (g *CoinGecko) UpdatePriceFromApi(priceCh chan<- []pricefeed.Price) {
-	log.Println("📡 Starting CoinGecko price update service")
-
-	for {
-		data, err := g.getPrices()
-		if err != nil {
-			log.Printf("❌ Error fetching prices: %v", err)
-		} else {
-			log.Printf("✅ Successfully fetched %d prices from CoinGecko", len(data))
-
-			priceCh <- data
-		}
-
-		// Rate limit as per CoinGecko's requirements
-		time.Sleep(61 * time.Second)
-	}
+func (g *CoinGecko) UpdatePriceFromApi(ctx context.Context, priceCh chan<- []pricefeed.Price) {
+	g.poll(ctx, priceCh, g.getPrices)
 }
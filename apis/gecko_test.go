@@ -1,6 +1,7 @@
 package apis
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -24,6 +25,7 @@ func TestNewCoinGecko(t *testing.T) {
 	assert.NotNil(t, gecko)
 	assert.Equal(t, cfg, gecko.cfg)
 	assert.NotNil(t, gecko.apiPrices)
+	assert.Equal(t, geckoInterval, gecko.Interval())
 }
 
 func TestGetPrices(t *testing.T) {
@@ -37,8 +39,8 @@ func TestGetPrices(t *testing.T) {
 
 		// Return mock response
 		response := map[string]CurrencyPrice{
-			"bitcoin":  {USD: 30000.00},
-			"ethereum": {USD: 2000.00},
+			"bitcoin":  {USD: pricefeed.MustDecimal("30000")},
+			"ethereum": {USD: pricefeed.MustDecimal("2000")},
 		}
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -51,19 +53,19 @@ func TestGetPrices(t *testing.T) {
 	}
 
 	gecko := NewCoinGecko(cfg)
-	prices, err := gecko.getPrices()
+	prices, err := gecko.getPrices(context.Background())
 
 	assert.NoError(t, err)
 	assert.Len(t, prices, 2)
 
 	// Verify prices
-	expectedPrices := map[string]float64{
-		"bitcoin":  30000.00,
-		"ethereum": 2000.00,
+	expectedPrices := map[string]string{
+		"bitcoin":  "30000",
+		"ethereum": "2000",
 	}
 
 	for _, price := range prices {
-		assert.Equal(t, expectedPrices[price.Symbol], price.USD)
+		assert.Equal(t, expectedPrices[price.Symbol], price.USD.String())
 	}
 }
 
@@ -72,9 +74,9 @@ func TestApiPrices(t *testing.T) {
 	gecko := NewCoinGecko(cfg)
 
 	// Set some test prices
-	gecko.apiPrices = map[string]float64{
-		"bitcoin":  30000.00,
-		"ethereum": 2000.00,
+	gecko.apiPrices = map[string]pricefeed.Decimal{
+		"bitcoin":  pricefeed.MustDecimal("30000"),
+		"ethereum": pricefeed.MustDecimal("2000"),
 	}
 
 	prices := gecko.ApiPrices()
@@ -85,7 +87,7 @@ func TestUpdatePriceFromApi(t *testing.T) {
 	// Create a test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response := map[string]CurrencyPrice{
-			"bitcoin": {USD: 30000.00},
+			"bitcoin": {USD: pricefeed.MustDecimal("30000")},
 		}
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -100,9 +102,12 @@ func TestUpdatePriceFromApi(t *testing.T) {
 	gecko := NewCoinGecko(cfg)
 	priceCh := make(chan []pricefeed.Price, 1)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Start UpdatePriceFromApi in a goroutine
 	go func() {
-		gecko.UpdatePriceFromApi(priceCh)
+		gecko.UpdatePriceFromApi(ctx, priceCh)
 	}()
 
 	// Wait for the first price update
@@ -110,7 +115,7 @@ func TestUpdatePriceFromApi(t *testing.T) {
 	case prices := <-priceCh:
 		assert.Len(t, prices, 1)
 		assert.Equal(t, "bitcoin", prices[0].Symbol)
-		assert.Equal(t, 30000.00, prices[0].USD)
+		assert.Equal(t, "30000", prices[0].USD.String())
 	case <-time.After(2 * time.Second):
 		t.Fatal("Timeout waiting for price update")
 	}
@@ -0,0 +1,182 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// defaultHTTPTimeout bounds every request made by an httpProvider
+const defaultHTTPTimeout = 10 * time.Second
+
+// httpProvider implements the request, decoding and polling logic shared by REST price providers
+type httpProvider struct {
+	name      string
+	client    *http.Client
+	symbols   map[string]string // token identifier -> exchange symbol
+	interval  time.Duration
+	apiPrices map[string]pricefeed.Decimal
+}
+
+// newHTTPProvider creates the shared provider base. Later symbol tables override earlier ones.
+func newHTTPProvider(name string, interval time.Duration, symbols ...map[string]string) httpProvider {
+	return httpProvider{
+		name: name,
+		client: &http.Client{
+			Timeout: defaultHTTPTimeout,
+		},
+		symbols:   mergeSymbols(symbols...),
+		interval:  interval,
+		apiPrices: make(map[string]pricefeed.Decimal),
+	}
+}
+
+// mergeSymbols combines token -> exchange symbol tables, later tables overriding earlier ones
+func mergeSymbols(tables ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+
+	for _, table := range tables {
+		for token, symbol := range table {
+			merged[token] = strings.ToUpper(symbol)
+		}
+	}
+
+	return merged
+}
+
+// exchangeSymbols maps a comma-separated token list to exchange symbols. It returns
+// a reverse lookup from exchange symbol to token and the symbols in input order.
+func (h *httpProvider) exchangeSymbols(tokens string) (map[string]string, []string, error) {
+	lookup := make(map[string]string)
+	ordered := make([]string, 0)
+
+	for _, token := range strings.Split(tokens, ",") {
+		token = strings.TrimSpace(token)
+
+		symbol, ok := h.symbols[token]
+		if !ok {
+			log.Printf("⚠️ No %s symbol for %s", h.name, token)
+
+			continue
+		}
+
+		lookup[symbol] = token
+		ordered = append(ordered, symbol)
+	}
+
+	if len(ordered) == 0 {
+		return nil, nil, fmt.Errorf("no configured tokens have a %s symbol", h.name)
+	}
+
+	return lookup, ordered, nil
+}
+
+// getJSON performs a GET request against endpoint and decodes the JSON response into out
+func (h *httpProvider) getJSON(ctx context.Context, endpoint string, params url.Values, out any) error {
+	fullURL := endpoint
+	if len(params) > 0 {
+		fullURL = fmt.Sprintf("%s?%s", endpoint, params.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prices: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("API rate limit exceeded: %d", resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned non-200 status: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// setPrice caches a fetched price and returns it as a pricefeed.Price
+func (h *httpProvider) setPrice(token string, usd pricefeed.Decimal) pricefeed.Price {
+	h.apiPrices[token] = usd
+
+	return pricefeed.Price{
+		Symbol: token,
+		USD:    usd,
+	}
+}
+
+// ApiPrices returns the current cached API prices
+func (h *httpProvider) ApiPrices() map[string]pricefeed.Decimal {
+	return h.apiPrices
+}
+
+// Interval returns the time between polls, which also respects the provider's rate limit
+func (h *httpProvider) Interval() time.Duration {
+	return h.interval
+}
+
+// durationOrDefault returns d, or fallback when d is not positive
+func durationOrDefault(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+
+	return d
+}
+
+// poll calls fetch every interval and sends successful results to priceCh
+// until ctx is canceled, then closes priceCh
+func (h *httpProvider) poll(
+	ctx context.Context,
+	priceCh chan<- []pricefeed.Price,
+	fetch func(ctx context.Context) ([]pricefeed.Price, error),
+) {
+	log.Printf("📡 Starting %s price update service", h.name)
+
+	defer close(priceCh)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		data, err := fetch(ctx)
+
+		switch {
+		case ctx.Err() != nil:
+		case err != nil:
+			log.Printf("❌ Error fetching %s prices: %v", h.name, err)
+		default:
+			log.Printf("✅ Successfully fetched %d prices from %s", len(data), h.name)
+
+			select {
+			case priceCh <- data:
+			case <-ctx.Done():
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Printf("🛑 Stopping %s price update service", h.name)
+
+			return
+		}
+	}
+}
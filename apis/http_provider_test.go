@@ -0,0 +1,98 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+func TestHTTPProviderExchangeSymbols(t *testing.T) {
+	provider := newHTTPProvider("Test", time.Second,
+		map[string]string{"bitcoin": "btc", "ethereum": "eth"},
+		map[string]string{"ethereum": "weth"},
+	)
+
+	tokens, symbols, err := provider.exchangeSymbols("bitcoin, ethereum,unknown")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BTC", "WETH"}, symbols)
+	assert.Equal(t, map[string]string{"BTC": "bitcoin", "WETH": "ethereum"}, tokens)
+
+	_, _, err = provider.exchangeSymbols("unknown")
+	assert.Error(t, err)
+}
+
+func TestHTTPProviderGetJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "success", status: http.StatusOK, body: `{"price":1.5}`},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantErr: "rate limit"},
+		{name: "server error", status: http.StatusBadGateway, wantErr: "non-200 status: 502"},
+		{name: "invalid json", status: http.StatusOK, body: `{`, wantErr: "failed to decode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "1", r.URL.Query().Get("q"))
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider := newHTTPProvider("Test", time.Second)
+
+			var out struct {
+				Price float64 `json:"price"`
+			}
+
+			err := provider.getJSON(context.Background(), server.URL, url.Values{"q": {"1"}}, &out)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1.5, out.Price)
+		})
+	}
+}
+
+func TestHTTPProviderPollStopsOnCancel(t *testing.T) {
+	provider := newHTTPProvider("Test", time.Hour)
+	priceCh := make(chan []pricefeed.Price, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+
+	go func() {
+		provider.poll(ctx, priceCh, func(context.Context) ([]pricefeed.Price, error) {
+			return []pricefeed.Price{{Symbol: "bitcoin", USD: pricefeed.DecimalFromInt(30000)}}, nil
+		})
+		close(done)
+	}()
+
+	assert.Len(t, <-priceCh, 1)
+
+	cancel()
+
+	select {
+	case <-done:
+		_, ok := <-priceCh
+		assert.False(t, ok, "channel should be closed after cancellation")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for poll to stop")
+	}
+}
@@ -0,0 +1,100 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sljivkov/dectek/config"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// defaultKrakenInterval is used when no polling interval is configured
+const defaultKrakenInterval = 10 * time.Second
+
+// krakenPairs maps our token identifiers to Kraken USD pair names as returned in ticker results
+var krakenPairs = map[string]string{
+	"bitcoin":     "XXBTZUSD",
+	"ethereum":    "XETHZUSD",
+	"solana":      "SOLUSD",
+	"ripple":      "XXRPZUSD",
+	"cardano":     "ADAUSD",
+	"dogecoin":    "XDGUSD",
+	"polkadot":    "DOTUSD",
+	"chainlink":   "LINKUSD",
+	"litecoin":    "XLTCZUSD",
+	"avalanche-2": "AVAXUSD",
+}
+
+// Kraken implements a price feed using the Kraken public ticker API
+type Kraken struct {
+	httpProvider
+	cfg config.Config
+}
+
+// KrakenTicker represents the ticker response structure from Kraken
+type KrakenTicker struct {
+	Error  []string `json:"error"`
+	Result map[string]struct {
+		LastTrade []string `json:"c"` // [price, lot volume]
+	} `json:"result"`
+}
+
+// NewKraken creates a new Kraken price feed instance
+func NewKraken(cfg config.Config) *Kraken {
+	return &Kraken{
+		httpProvider: newHTTPProvider("Kraken",
+			durationOrDefault(cfg.KrakenInterval, defaultKrakenInterval), krakenPairs, cfg.KrakenPairs),
+		cfg: cfg,
+	}
+}
+
+// getPrices fetches current prices from the Kraken ticker API
+func (k *Kraken) getPrices(ctx context.Context) ([]pricefeed.Price, error) {
+	tokens, pairs, err := k.exchangeSymbols(k.cfg.Tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Add("pair", strings.Join(pairs, ","))
+
+	var raw KrakenTicker
+	if err := k.getJSON(ctx, k.cfg.KrakenUrl, params, &raw); err != nil {
+		return nil, err
+	}
+
+	if len(raw.Error) > 0 {
+		return nil, fmt.Errorf("API returned errors: %s", strings.Join(raw.Error, "; "))
+	}
+
+	prices := make([]pricefeed.Price, 0, len(raw.Result))
+
+	for pair, ticker := range raw.Result {
+		token, ok := tokens[pair]
+		if !ok || len(ticker.LastTrade) == 0 {
+			continue
+		}
+
+		usd, err := pricefeed.ParseDecimal(ticker.LastTrade[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s price %q: %w", pair, ticker.LastTrade[0], err)
+		}
+
+		prices = append(prices, k.setPrice(token, usd))
+	}
+
+	return prices, nil
+}
+
+// FetchPrices fetches current prices once, for use as a reference price
+func (k *Kraken) FetchPrices(ctx context.Context) ([]pricefeed.Price, error) {
+	return k.getPrices(ctx)
+}
+
+// UpdatePriceFromApi continuously updates prices from the Kraken API
+func (k *Kraken) UpdatePriceFromApi(ctx context.Context, priceCh chan<- []pricefeed.Price) {
+	k.poll(ctx, priceCh, k.getPrices)
+}
@@ -0,0 +1,69 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/config"
+)
+
+func TestNewKraken(t *testing.T) {
+	cfg := config.Config{
+		Tokens:    "bitcoin",
+		KrakenUrl: "http://test.com",
+	}
+
+	kraken := NewKraken(cfg)
+	assert.NotNil(t, kraken)
+	assert.Equal(t, cfg, kraken.cfg)
+	assert.Equal(t, "XXBTZUSD", kraken.symbols["bitcoin"])
+	assert.Equal(t, defaultKrakenInterval, kraken.interval)
+}
+
+func TestKrakenGetPrices(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify query parameters
+		assert.Equal(t, "XXBTZUSD,XETHZUSD", r.URL.Query().Get("pair"))
+
+		// Return mock response
+		w.Write([]byte(`{"error":[],"result":{` +
+			`"XXBTZUSD":{"c":["30000.10000","0.01"]},` +
+			`"XETHZUSD":{"c":["2000.50000","1.5"]}}}`))
+	}))
+	defer server.Close()
+
+	kraken := NewKraken(config.Config{
+		Tokens:    "bitcoin,ethereum",
+		KrakenUrl: server.URL,
+	})
+
+	prices, err := kraken.getPrices(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, prices, 2)
+
+	// Verify prices
+	expectedPrices := map[string]string{
+		"bitcoin":  "30000.1",
+		"ethereum": "2000.5",
+	}
+
+	for _, price := range prices {
+		assert.Equal(t, expectedPrices[price.Symbol], price.USD.String())
+	}
+}
+
+func TestKrakenGetPrices_ApiError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"error":["EQuery:Unknown asset pair"],"result":{}}`))
+	}))
+	defer server.Close()
+
+	kraken := NewKraken(config.Config{Tokens: "bitcoin", KrakenUrl: server.URL})
+	_, err := kraken.getPrices(context.Background())
+	assert.ErrorContains(t, err, "Unknown asset pair")
+}
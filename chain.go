@@ -22,8 +22,8 @@ func (af *AllFeed) WritePricesToChain(ctx context.Context, in <-chan []pricefeed
 	af.chainFeed.WritePricesToChain(ctx, in)
 }
 
-func (af *AllFeed) UpdatePriceFromApi(priceCh chan<- []pricefeed.Price) {
-	af.apiFeed.UpdatePriceFromApi(priceCh)
+func (af *AllFeed) UpdatePriceFromApi(ctx context.Context, priceCh chan<- []pricefeed.Price) {
+	af.apiFeed.UpdatePriceFromApi(ctx, priceCh)
 }
 
 func NewAllFeed(apiFeed pricefeed.PriceProvider, chainFeed pricefeed.PriceFeed) *AllFeed {
@@ -0,0 +1,109 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// defaultBackfillPageSize bounds the block range of a single log query when none is configured
+const defaultBackfillPageSize = 5000
+
+// Backfill replays PriceChanged events up to the current head so that validation after a restart
+// sees the same on-chain prices as in steady state. It starts after the last processed block, which
+// survives restarts when STATE_FILE is set, or at BackfillFromBlock when none was processed, and
+// queries logs in pages of BackfillPageSize blocks.
+// Replayed prices are sent to out in block order.
+func (s *SepoliaPriceFeed) Backfill(ctx context.Context, out chan<- pricefeed.Price) error {
+	start := s.cfg.BackfillFromBlock
+	if last := s.LastBlock(); last > 0 {
+		start = last + 1
+	}
+
+	if start == 0 {
+		log.Println("⏭️ No backfill start block configured, skipping PriceChanged backfill")
+
+		return nil
+	}
+
+	_, err := s.replay(ctx, start, out)
+
+	return err
+}
+
+// replay applies the PriceChanged events from start to the current head in pages,
+// sends them to out and returns the head it replayed up to
+func (s *SepoliaPriceFeed) replay(ctx context.Context, start uint64, out chan<- pricefeed.Price) (uint64, error) {
+	head, err := s.confirmedHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pageSize := s.cfg.BackfillPageSize
+	if pageSize == 0 {
+		pageSize = defaultBackfillPageSize
+	}
+
+	log.Printf("⏪ Replaying PriceChanged events from block %d to %d", start, head)
+
+	events := 0
+
+	for from := start; from <= head; from += pageSize {
+		to := min(from+pageSize-1, head)
+
+		n, err := s.backfillRange(ctx, from, to, out)
+		if err != nil {
+			return 0, err
+		}
+
+		events += n
+
+		// A restart resumes after the last completed page
+		s.setLastBlock(to)
+		s.saveState()
+	}
+
+	s.setLastBlock(head)
+
+	log.Printf("✅ Replayed %d PriceChanged events, %d prices cached", events, len(s.OnChainPrices()))
+
+	return head, nil
+}
+
+// backfillRange applies the PriceChanged events of blocks [from, to] and returns how many were applied
+func (s *SepoliaPriceFeed) backfillRange(ctx context.Context, from, to uint64,
+	out chan<- pricefeed.Price,
+) (int, error) {
+	it, err := s.contract.FilterPriceChanged(&bind.FilterOpts{Start: from, End: &to, Context: ctx})
+	if err != nil {
+		return 0, fmt.Errorf("failed to filter PriceChanged events in blocks %d-%d: %w", from, to, err)
+	}
+
+	defer it.Close()
+
+	events := 0
+
+	for it.Next() {
+		if it.Event.Raw.Removed {
+			continue
+		}
+
+		price := s.applyPriceChanged(it.Event)
+		s.recordHistory(it.Event, price)
+		events++
+
+		if err := emit(ctx, out, price); err != nil {
+			return events, err
+		}
+	}
+
+	if err := it.Error(); err != nil {
+		return events, fmt.Errorf("failed to read PriceChanged events in blocks %d-%d: %w", from, to, err)
+	}
+
+	return events, nil
+}
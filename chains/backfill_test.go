@@ -0,0 +1,155 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/contract"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// staticLogFilterer implements bind.ContractFilterer over a fixed set of logs
+type staticLogFilterer struct {
+	logs []types.Log
+}
+
+func (f *staticLogFilterer) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return f.logs, nil
+}
+
+func (f *staticLogFilterer) SubscribeFilterLogs(context.Context, ethereum.FilterQuery,
+	chan<- types.Log,
+) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(<-chan struct{}) error { return nil }), nil
+}
+
+// priceChangedLog is a PriceChanged event to encode into a log
+type priceChangedLog struct {
+	block     uint64
+	symbol    string
+	price     int64
+	timestamp int64
+	index     uint // Log index within the block's transaction
+}
+
+// priceChangedIterator returns a generated event iterator over the given events
+func priceChangedIterator(t *testing.T, events ...priceChangedLog) *contract.ContractPriceChangedIterator {
+	t.Helper()
+
+	parsedABI, err := contract.ContractMetaData.GetAbi()
+	assert.NoError(t, err)
+
+	priceChanged := parsedABI.Events["PriceChanged"]
+
+	logs := make([]types.Log, 0, len(events))
+
+	for _, e := range events {
+		data, err := priceChanged.Inputs.NonIndexed().Pack(e.symbol, big.NewInt(e.price), big.NewInt(e.timestamp))
+		assert.NoError(t, err)
+
+		logs = append(logs, types.Log{
+			Topics:      []common.Hash{priceChanged.ID},
+			Data:        data,
+			BlockNumber: e.block,
+			TxHash:      common.BigToHash(new(big.Int).SetUint64(e.block)),
+			Index:       e.index,
+		})
+	}
+
+	filterer, err := contract.NewContractFilterer(common.Address{}, &staticLogFilterer{logs: logs})
+	assert.NoError(t, err)
+
+	it, err := filterer.FilterPriceChanged(nil)
+	assert.NoError(t, err)
+
+	return it
+}
+
+func TestBackfill(t *testing.T) {
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+
+	cfg := testConfig()
+	cfg.BackfillFromBlock = 100
+	cfg.BackfillPageSize = 50
+
+	feed := &SepoliaPriceFeed{
+		cfg:           cfg,
+		contract:      mockContract,
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+	}
+
+	mockBackend.On("BlockNumber").Return(uint64(220), nil)
+
+	// Blocks 100-220 are read in three pages
+	mockContract.On("FilterPriceChanged", uint64(100), uint64(149)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 120, symbol: "bitcoin", price: 3000000, timestamp: 1_700_000_000},
+	), nil)
+	mockContract.On("FilterPriceChanged", uint64(150), uint64(199)).Return(priceChangedIterator(t), nil)
+	mockContract.On("FilterPriceChanged", uint64(200), uint64(220)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 205, symbol: "ethereum", price: 200012, timestamp: 1_700_000_100},
+		priceChangedLog{block: 210, symbol: "bitcoin", price: 3100000, timestamp: 1_700_000_200},
+	), nil)
+
+	out := make(chan pricefeed.Price, 10)
+
+	err := feed.Backfill(context.Background(), out)
+	assert.NoError(t, err)
+	close(out)
+
+	var replayed []string
+	for p := range out {
+		replayed = append(replayed, p.Symbol+"="+p.USD.String())
+	}
+
+	assert.Equal(t, []string{"bitcoin=30000", "ethereum=2000.12", "bitcoin=31000"}, replayed)
+	assert.Equal(t, "31000", feed.OnChainPrices()["bitcoin"].String())
+	assert.Equal(t, "2000.12", feed.OnChainPrices()["ethereum"].String())
+	assert.Equal(t, int64(1_700_000_200), feed.updatedAt["bitcoin"].Unix())
+	assert.Equal(t, uint64(220), feed.LastBlock())
+	mockContract.AssertExpectations(t)
+
+	// A second backfill resumes after the last processed block
+	mockBackend.ExpectedCalls = nil
+	mockBackend.On("BlockNumber").Return(uint64(230), nil)
+	mockContract.On("FilterPriceChanged", uint64(221), uint64(230)).Return(priceChangedIterator(t), nil)
+
+	err = feed.Backfill(context.Background(), make(chan pricefeed.Price))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(230), feed.LastBlock())
+}
+
+func TestBackfillErrors(t *testing.T) {
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+
+	feed := &SepoliaPriceFeed{
+		cfg:           testConfig(),
+		contract:      mockContract,
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+	}
+
+	// Without a start block nothing is queried
+	err := feed.Backfill(context.Background(), make(chan pricefeed.Price))
+	assert.NoError(t, err)
+	mockBackend.AssertNotCalled(t, "BlockNumber")
+
+	feed.cfg.BackfillFromBlock = 10
+
+	mockBackend.On("BlockNumber").Return(uint64(20), nil)
+	mockContract.On("FilterPriceChanged", uint64(10), uint64(20)).Return(nil, errors.New("range too large"))
+
+	err = feed.Backfill(context.Background(), make(chan pricefeed.Price))
+	assert.ErrorContains(t, err, "range too large")
+	assert.Equal(t, uint64(0), feed.LastBlock())
+}
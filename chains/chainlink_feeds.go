@@ -0,0 +1,198 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/sljivkov/dectek/config"
+)
+
+//nolint:lll
+const feedRegistryABI = `[{"inputs":[{"internalType":"address","name":"base","type":"address"},{"internalType":"address","name":"quote","type":"address"}],"name":"getFeed","outputs":[{"internalType":"contract AggregatorV2V3Interface","name":"aggregator","type":"address"}],"stateMutability":"view","type":"function"}]`
+
+// ErrMissingChainlinkFeeds is returned when some configured tokens have no Chainlink feed
+var ErrMissingChainlinkFeeds = errors.New("no Chainlink reference feed")
+
+// chainlinkUSD is the Feed Registry denomination for USD
+var chainlinkUSD = common.HexToAddress("0x0000000000000000000000000000000000000348")
+
+// defaultChainlinkBases maps tokens to their Feed Registry base denominations
+var defaultChainlinkBases = map[string]string{
+	"bitcoin":  "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+	"ethereum": "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE",
+}
+
+// defaultChainlinkFeeds lists known USD aggregators per network, used for tokens that are not configured
+var defaultChainlinkFeeds = map[string]map[string]string{
+	"sepolia": {
+		"bitcoin":  "0xA39434A63A52E749F02807ae27335515BA4b07F7",
+		"ethereum": "0xD4a33860578De61DBAbDc8BFdb98FD742fA7028e",
+	},
+}
+
+// chainNetworks names networks by chain ID for feed lookups
+var chainNetworks = map[uint64]string{
+	1:        "mainnet",
+	11155111: "sepolia",
+}
+
+// LoadChainlinkFeeds reads a JSON file mapping network -> token -> aggregator address
+func LoadChainlinkFeeds(path string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Chainlink feeds file: %w", err)
+	}
+
+	var feeds map[string]map[string]string
+	if err := json.Unmarshal(data, &feeds); err != nil {
+		return nil, fmt.Errorf("failed to parse Chainlink feeds file %s: %w", path, err)
+	}
+
+	return feeds, nil
+}
+
+// ChainlinkNetwork returns the network name used for feed lookups
+func ChainlinkNetwork(cfg config.Config, chainID *big.Int) string {
+	if cfg.ChainlinkNetwork != "" {
+		return cfg.ChainlinkNetwork
+	}
+
+	if name, ok := chainNetworks[chainID.Uint64()]; ok {
+		return name
+	}
+
+	return chainID.String()
+}
+
+// ResolveChainlinkFeeds returns the USD aggregator of every configured token. Addresses come from,
+// in order: CHAINLINK_FEEDS, the feeds file entry for the network, the built-in defaults and finally
+// the Feed Registry when CHAINLINK_REGISTRY is set. Tokens without a feed are reported in an error
+// wrapping ErrMissingChainlinkFeeds, returned along with the feeds that were resolved.
+func ResolveChainlinkFeeds(
+	ctx context.Context,
+	cfg config.Config,
+	caller bind.ContractCaller,
+	chainID *big.Int,
+) (map[string]common.Address, error) {
+	network := ChainlinkNetwork(cfg, chainID)
+
+	configured := make(map[string]string)
+	for token, addr := range defaultChainlinkFeeds[network] {
+		configured[token] = addr
+	}
+
+	if cfg.ChainlinkFeedsFile != "" {
+		file, err := LoadChainlinkFeeds(cfg.ChainlinkFeedsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for token, addr := range file[network] {
+			configured[token] = addr
+		}
+	}
+
+	for token, addr := range cfg.ChainlinkFeeds {
+		configured[token] = addr
+	}
+
+	feeds := make(map[string]common.Address)
+
+	var missing []string
+
+	for _, token := range cfg.TokenList() {
+		if addr, ok := configured[token]; ok {
+			if !common.IsHexAddress(addr) {
+				return nil, fmt.Errorf("invalid Chainlink feed address %q for %s", addr, token)
+			}
+
+			feeds[token] = common.HexToAddress(addr)
+
+			continue
+		}
+
+		if cfg.ChainlinkRegistry != "" {
+			addr, err := registryFeed(ctx, cfg, caller, token)
+			if err != nil {
+				return nil, err
+			}
+
+			if addr != (common.Address{}) {
+				feeds[token] = addr
+
+				continue
+			}
+		}
+
+		missing = append(missing, token)
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+
+		return feeds, fmt.Errorf("%w on %s for: %s", ErrMissingChainlinkFeeds, network, strings.Join(missing, ", "))
+	}
+
+	return feeds, nil
+}
+
+// registryFeed looks up the USD aggregator of a token in the Feed Registry,
+// returning the zero address when the registry has no feed for it
+func registryFeed(
+	ctx context.Context,
+	cfg config.Config,
+	caller bind.ContractCaller,
+	token string,
+) (common.Address, error) {
+	if !common.IsHexAddress(cfg.ChainlinkRegistry) {
+		return common.Address{}, fmt.Errorf("invalid Chainlink Feed Registry address %q", cfg.ChainlinkRegistry)
+	}
+
+	base, ok := cfg.ChainlinkBases[token]
+	if !ok {
+		base, ok = defaultChainlinkBases[token]
+	}
+
+	if !ok {
+		return common.Address{}, nil
+	}
+
+	if !common.IsHexAddress(base) {
+		return common.Address{}, fmt.Errorf("invalid Chainlink base address %q for %s", base, token)
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(feedRegistryABI))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to parse Feed Registry ABI: %w", err)
+	}
+
+	registry := bind.NewBoundContract(common.HexToAddress(cfg.ChainlinkRegistry), parsedABI, caller, nil, nil)
+
+	var out []any
+
+	err = registry.Call(&bind.CallOpts{Context: ctx}, &out, "getFeed", common.HexToAddress(base), chainlinkUSD)
+	if err != nil {
+		// The registry reverts for unknown pairs
+		log.Printf("⚠️ Feed Registry has no %s/USD feed: %v", token, err)
+
+		return common.Address{}, nil
+	}
+
+	addr, ok := out[0].(common.Address)
+	if !ok {
+		return common.Address{}, fmt.Errorf("invalid Feed Registry response for %s", token)
+	}
+
+	return addr, nil
+}
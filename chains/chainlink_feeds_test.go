@@ -0,0 +1,146 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/config"
+)
+
+func TestResolveChainlinkFeeds(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "feeds.json")
+	err := os.WriteFile(file, []byte(`{
+		"sepolia": {"ethereum": "0x1111111111111111111111111111111111111111"},
+		"mainnet": {"bitcoin": "0x2222222222222222222222222222222222222222"}
+	}`), 0o600)
+	assert.NoError(t, err)
+
+	sepolia := big.NewInt(11155111)
+
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		chainID *big.Int
+		want    map[string]common.Address
+		wantErr string
+	}{
+		{
+			name:    "built-in defaults",
+			cfg:     config.Config{Tokens: "bitcoin,ethereum"},
+			chainID: sepolia,
+			want: map[string]common.Address{
+				"bitcoin":  common.HexToAddress("0xA39434A63A52E749F02807ae27335515BA4b07F7"),
+				"ethereum": common.HexToAddress("0xD4a33860578De61DBAbDc8BFdb98FD742fA7028e"),
+			},
+		},
+		{
+			name: "env overrides file overrides defaults",
+			cfg: config.Config{
+				Tokens:             "bitcoin,ethereum",
+				ChainlinkFeedsFile: file,
+				ChainlinkFeeds:     map[string]string{"bitcoin": "0x3333333333333333333333333333333333333333"},
+			},
+			chainID: sepolia,
+			want: map[string]common.Address{
+				"bitcoin":  common.HexToAddress("0x3333333333333333333333333333333333333333"),
+				"ethereum": common.HexToAddress("0x1111111111111111111111111111111111111111"),
+			},
+		},
+		{
+			name:    "network from config",
+			cfg:     config.Config{Tokens: "bitcoin", ChainlinkNetwork: "mainnet", ChainlinkFeedsFile: file},
+			chainID: sepolia,
+			want: map[string]common.Address{
+				"bitcoin": common.HexToAddress("0x2222222222222222222222222222222222222222"),
+			},
+		},
+		{
+			name:    "missing feeds are reported",
+			cfg:     config.Config{Tokens: "bitcoin,tether,dogecoin"},
+			chainID: sepolia,
+			want: map[string]common.Address{
+				"bitcoin": common.HexToAddress("0xA39434A63A52E749F02807ae27335515BA4b07F7"),
+			},
+			wantErr: "no Chainlink reference feed on sepolia for: dogecoin, tether",
+		},
+		{
+			name:    "unknown network",
+			cfg:     config.Config{Tokens: "bitcoin"},
+			chainID: big.NewInt(5),
+			want:    map[string]common.Address{},
+			wantErr: "no Chainlink reference feed on 5 for: bitcoin",
+		},
+		{
+			name: "invalid address",
+			cfg: config.Config{
+				Tokens:         "bitcoin",
+				ChainlinkFeeds: map[string]string{"bitcoin": "not-an-address"},
+			},
+			chainID: sepolia,
+			wantErr: "invalid Chainlink feed address",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feeds, err := ResolveChainlinkFeeds(context.Background(), tt.cfg, new(MockContractCaller), tt.chainID)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				// Feeds that were found are still returned along with the missing ones
+				if errors.Is(err, ErrMissingChainlinkFeeds) {
+					assert.Equal(t, tt.want, feeds)
+				}
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, feeds)
+		})
+	}
+}
+
+func TestResolveChainlinkFeedsRegistry(t *testing.T) {
+	registry := common.HexToAddress("0x47Fb2585D2C56Fe188D0E6ec628a38b74fCeeeDf")
+	aggregator := common.HexToAddress("0xF4030086522a5bEEa4988F8cA5B36dbC97BeE88c")
+
+	parsedABI, err := abi.JSON(strings.NewReader(feedRegistryABI))
+	assert.NoError(t, err)
+
+	output, err := parsedABI.Methods["getFeed"].Outputs.Pack(aggregator)
+	assert.NoError(t, err)
+
+	mockCaller := new(MockContractCaller)
+	mockCaller.On("CallContract", registry).Return(output, nil).Once()
+	mockCaller.On("CallContract", registry).Return([]byte(nil), errors.New("execution reverted: Feed not found"))
+
+	cfg := config.Config{
+		Tokens:            "bitcoin,ethereum,tether",
+		ChainlinkNetwork:  "mainnet",
+		ChainlinkRegistry: registry.Hex(),
+		ChainlinkBases:    map[string]string{"ethereum": "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE"},
+	}
+
+	// bitcoin resolves through the registry, ethereum's pair reverts and tether has no base address
+	_, err = ResolveChainlinkFeeds(context.Background(), cfg, mockCaller, big.NewInt(1))
+	assert.EqualError(t, err, "no Chainlink reference feed on mainnet for: ethereum, tether")
+
+	mockCaller.On("CallContract", registry).Unset()
+	mockCaller.On("CallContract", registry).Return(output, nil)
+
+	cfg.Tokens = "bitcoin"
+
+	feeds, err := ResolveChainlinkFeeds(context.Background(), cfg, mockCaller, big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]common.Address{"bitcoin": aggregator}, feeds)
+}
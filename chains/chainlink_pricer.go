@@ -2,63 +2,220 @@
 package chains
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/sljivkov/dectek/config"
+	"github.com/sljivkov/dectek/pricefeed"
 )
 
-// RealChainlinkPricer implements ChainlinkPricer interface using real Chainlink price feeds
+// ChainlinkRound is the latestRoundData answer of a Chainlink feed
+type ChainlinkRound struct {
+	RoundID         *big.Int
+	Answer          *big.Int // Price in units of 10^-Decimals USD
+	Decimals        uint8
+	StartedAt       time.Time
+	UpdatedAt       time.Time
+	AnsweredInRound *big.Int
+}
+
+// Price returns the answer as a USD price
+func (r ChainlinkRound) Price() pricefeed.Decimal {
+	return pricefeed.DecimalFromUnits(r.Answer, r.Decimals)
+}
+
+// check rejects answers that are non-positive, from incomplete rounds or older than maxAge
+func (r ChainlinkRound) check(maxAge time.Duration, now time.Time) error {
+	if r.Answer == nil || r.Answer.Sign() <= 0 {
+		return fmt.Errorf("non-positive answer %v in round %v", r.Answer, r.RoundID)
+	}
+
+	if r.UpdatedAt.IsZero() {
+		return fmt.Errorf("round %v is incomplete", r.RoundID)
+	}
+
+	if r.AnsweredInRound == nil || r.RoundID == nil || r.AnsweredInRound.Cmp(r.RoundID) < 0 {
+		return fmt.Errorf("round %v was answered in earlier round %v", r.RoundID, r.AnsweredInRound)
+	}
+
+	if age := now.Sub(r.UpdatedAt); maxAge > 0 && age > maxAge {
+		return fmt.Errorf("answer is stale: updated %s ago, max age %s", age.Truncate(time.Second), maxAge)
+	}
+
+	return nil
+}
+
+// RealChainlinkPricer implements ReferencePricer using real Chainlink price feeds
 type RealChainlinkPricer struct {
-	client *ethclient.Client
+	cfg       config.Config
+	caller    bind.ContractCaller
+	feeds     map[string]common.Address
+	parsedABI abi.ABI
+	now       func() time.Time
+
+	decimals   map[common.Address]uint8 // feed -> answer decimals, read once per feed
+	decimalsMu sync.Mutex
 }
 
-// NewRealChainlinkPricer creates a new instance of RealChainlinkPricer
-func NewRealChainlinkPricer(client *ethclient.Client) *RealChainlinkPricer {
-	return &RealChainlinkPricer{client: client}
+// NewRealChainlinkPricer creates a new instance of RealChainlinkPricer reading the given
+// token -> aggregator feeds, usually resolved with ResolveChainlinkFeeds
+func NewRealChainlinkPricer(cfg config.Config, caller bind.ContractCaller,
+	feeds map[string]common.Address,
+) (*RealChainlinkPricer, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(chainlinkABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Chainlink ABI: %w", err)
+	}
+
+	return &RealChainlinkPricer{
+		cfg:       cfg,
+		caller:    caller,
+		feeds:     feeds,
+		parsedABI: parsedABI,
+		now:       time.Now,
+		decimals:  make(map[common.Address]uint8),
+	}, nil
 }
 
-//nolint:lll
-const chainlinkABI = `[{"inputs":[],"name":"latestRoundData","outputs":[{"internalType":"uint80","name":"roundId","type":"uint80"},{"internalType":"int256","name":"answer","type":"int256"},{"internalType":"uint256","name":"startedAt","type":"uint256"},{"internalType":"uint256","name":"updatedAt","type":"uint256"},{"internalType":"uint80","name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"}]`
+// NewChainlinkReference resolves the Chainlink feeds of the client's network and returns a pricer reading them.
+// With allowMissing, tokens without a feed are logged and left to the next reference instead of failing.
+func NewChainlinkReference(ctx context.Context, cfg config.Config,
+	client *ethclient.Client, allowMissing bool,
+) (*RealChainlinkPricer, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chain ID: %w", err)
+	}
 
-// getChainlinkPrice fetches the latest price for a given token from Chainlink price feeds
-func (r *RealChainlinkPricer) getChainlinkPrice(symbol string) (int64, error) {
-	addresses := map[string]string{
-		"bitcoin":  "0xA39434A63A52E749F02807ae27335515BA4b07F7",
-		"ethereum": "0xD4a33860578De61DBAbDc8BFdb98FD742fA7028e",
+	feeds, err := ResolveChainlinkFeeds(ctx, cfg, client, chainID)
+	if allowMissing && errors.Is(err, ErrMissingChainlinkFeeds) {
+		log.Printf("⚠️ %v, falling back to the next reference", err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return NewRealChainlinkPricer(cfg, client, feeds)
+}
+
+// Name returns "chainlink"
+func (r *RealChainlinkPricer) Name() string {
+	return "chainlink"
+}
+
+// ReferencePrice returns the USD price of the latest accepted Chainlink round
+func (r *RealChainlinkPricer) ReferencePrice(ctx context.Context, symbol string) (pricefeed.Decimal, error) {
+	round, err := r.latestRound(ctx, symbol)
+	if err != nil {
+		return pricefeed.Decimal{}, err
 	}
 
-	addr, ok := addresses[symbol]
+	return round.Price(), nil
+}
+
+//nolint:lll
+const chainlinkABI = `[{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"latestRoundData","outputs":[{"internalType":"uint80","name":"roundId","type":"uint80"},{"internalType":"int256","name":"answer","type":"int256"},{"internalType":"uint256","name":"startedAt","type":"uint256"},{"internalType":"uint256","name":"updatedAt","type":"uint256"},{"internalType":"uint80","name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"}]`
+
+// latestRound fetches the latest round of a token's Chainlink feed and rejects unusable answers
+func (r *RealChainlinkPricer) latestRound(ctx context.Context, symbol string) (ChainlinkRound, error) {
+	contractAddr, ok := r.feeds[symbol]
 	if !ok {
-		return 0, fmt.Errorf("no Chainlink price feed available for %s", symbol)
+		return ChainlinkRound{}, fmt.Errorf("no Chainlink price feed available for %s", symbol)
 	}
 
-	contractAddr := common.HexToAddress(addr)
+	contract := bind.NewBoundContract(contractAddr, r.parsedABI, r.caller, nil, nil)
 
-	parsedABI, err := abi.JSON(strings.NewReader(chainlinkABI))
+	decimals, err := r.feedDecimals(ctx, contract, contractAddr)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse Chainlink ABI: %w", err)
+		return ChainlinkRound{}, err
+	}
+
+	var out []any
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "latestRoundData"); err != nil {
+		return ChainlinkRound{}, fmt.Errorf("failed to fetch Chainlink price data: %w", err)
 	}
 
-	contract := bind.NewBoundContract(contractAddr, parsedABI, r.client, r.client, r.client)
+	round, err := parseRound(out, decimals)
+	if err != nil {
+		return ChainlinkRound{}, err
+	}
+
+	if err := round.check(r.cfg.Token(symbol).MaxAge, r.now()); err != nil {
+		return ChainlinkRound{}, fmt.Errorf("rejected Chainlink %s answer: %w", symbol, err)
+	}
+
+	log.Printf("🔗 Chainlink %s: %s (round %s)", symbol, round.Price(), round.RoundID)
+
+	return round, nil
+}
+
+// feedDecimals returns the answer decimals of a feed, calling decimals() only on first use
+func (r *RealChainlinkPricer) feedDecimals(ctx context.Context, contract *bind.BoundContract,
+	addr common.Address,
+) (uint8, error) {
+	r.decimalsMu.Lock()
+	defer r.decimalsMu.Unlock()
+
+	if decimals, ok := r.decimals[addr]; ok {
+		return decimals, nil
+	}
 
 	var out []any
-	if err := contract.Call(nil, &out, "latestRoundData"); err != nil {
-		return 0, fmt.Errorf("failed to fetch Chainlink price data: %w", err)
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "decimals"); err != nil {
+		return 0, fmt.Errorf("failed to fetch Chainlink feed decimals: %w", err)
 	}
 
-	answer, ok := out[1].(*big.Int)
-	if !ok || answer == nil {
-		return 0, fmt.Errorf("invalid price data received from Chainlink")
+	decimals, ok := out[0].(uint8)
+	if !ok {
+		return 0, fmt.Errorf("invalid decimals received from Chainlink")
 	}
 
-	price := new(big.Int).Div(answer, big.NewInt(1e6)).Int64()
-	log.Printf("🔗 Chainlink %s: %d", symbol, price)
+	r.decimals[addr] = decimals
+
+	return decimals, nil
+}
+
+// parseRound converts unpacked latestRoundData outputs into a ChainlinkRound
+func parseRound(out []any, decimals uint8) (ChainlinkRound, error) {
+	if len(out) != 5 {
+		return ChainlinkRound{}, fmt.Errorf("invalid price data received from Chainlink")
+	}
+
+	values := make([]*big.Int, len(out))
+	for i, v := range out {
+		n, ok := v.(*big.Int)
+		if !ok || n == nil {
+			return ChainlinkRound{}, fmt.Errorf("invalid price data received from Chainlink")
+		}
+
+		values[i] = n
+	}
+
+	return ChainlinkRound{
+		RoundID:         values[0],
+		Answer:          values[1],
+		Decimals:        decimals,
+		StartedAt:       unixTime(values[2]),
+		UpdatedAt:       unixTime(values[3]),
+		AnsweredInRound: values[4],
+	}, nil
+}
+
+// unixTime converts a Unix timestamp in seconds, treating 0 as the zero time
+func unixTime(seconds *big.Int) time.Time {
+	if seconds.Sign() == 0 || !seconds.IsInt64() {
+		return time.Time{}
+	}
 
-	return price, nil
+	return time.Unix(seconds.Int64(), 0)
 }
@@ -0,0 +1,133 @@
+package chains
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sljivkov/dectek/config"
+)
+
+// MockFeedCaller implements bind.ContractCaller, answering calls by contract address and method selector
+type MockFeedCaller struct {
+	mock.Mock
+}
+
+func (m *MockFeedCaller) CodeAt(_ context.Context, _ common.Address, _ *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (m *MockFeedCaller) CallContract(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	args := m.Called(*call.To, string(call.Data[:4]))
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func TestChainlinkRoundCheck(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	round := func(answer int64, updatedAt time.Time, answeredIn int64) ChainlinkRound {
+		return ChainlinkRound{
+			RoundID:         big.NewInt(10),
+			Answer:          big.NewInt(answer),
+			Decimals:        8,
+			UpdatedAt:       updatedAt,
+			AnsweredInRound: big.NewInt(answeredIn),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		round   ChainlinkRound
+		maxAge  time.Duration
+		wantErr string
+	}{
+		{name: "fresh", round: round(3_000_000_000_000, now.Add(-time.Minute), 10), maxAge: time.Hour},
+		{name: "age check disabled", round: round(3_000_000_000_000, now.Add(-48*time.Hour), 10)},
+		{
+			name:    "stale",
+			round:   round(3_000_000_000_000, now.Add(-2*time.Hour), 10),
+			maxAge:  time.Hour,
+			wantErr: "answer is stale",
+		},
+		{name: "zero answer", round: round(0, now, 10), wantErr: "non-positive answer"},
+		{name: "negative answer", round: round(-1, now, 10), wantErr: "non-positive answer"},
+		{name: "incomplete round", round: round(3_000_000_000_000, time.Time{}, 10), wantErr: "incomplete"},
+		{name: "carried over answer", round: round(3_000_000_000_000, now, 9), wantErr: "answered in earlier round"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.round.check(tt.maxAge, now)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLatestRound(t *testing.T) {
+	feedAddr := common.HexToAddress("0xA39434A63A52E749F02807ae27335515BA4b07F7")
+	now := time.Unix(1_700_000_000, 0)
+	mockCaller := new(MockFeedCaller)
+
+	pricer, err := NewRealChainlinkPricer(config.Config{
+		ChainlinkMaxAge:  time.Hour,
+		ChainlinkMaxAges: map[string]time.Duration{"tether": 24 * time.Hour},
+	}, mockCaller, map[string]common.Address{"bitcoin": feedAddr, "tether": feedAddr})
+	assert.NoError(t, err)
+
+	pricer.now = func() time.Time { return now }
+
+	decimals := pricer.parsedABI.Methods["decimals"]
+	latest := pricer.parsedABI.Methods["latestRoundData"]
+
+	decimalsOut, err := decimals.Outputs.Pack(uint8(8))
+	assert.NoError(t, err)
+
+	mockCaller.On("CallContract", feedAddr, string(decimals.ID)).Return(decimalsOut, nil)
+
+	roundOut := func(updatedAt time.Time) []byte {
+		out, err := latest.Outputs.Pack(big.NewInt(42), big.NewInt(3_000_012_345_678), big.NewInt(updatedAt.Unix()),
+			big.NewInt(updatedAt.Unix()), big.NewInt(42))
+		assert.NoError(t, err)
+
+		return out
+	}
+
+	mockCaller.On("CallContract", feedAddr, string(latest.ID)).Return(roundOut(now.Add(-time.Minute)), nil).Twice()
+
+	round, err := pricer.latestRound(context.Background(), "bitcoin")
+	assert.NoError(t, err)
+	assert.Equal(t, "30000.12345678", round.Price().String())
+	assert.Equal(t, big.NewInt(42), round.RoundID)
+	assert.Equal(t, now.Add(-time.Minute), round.UpdatedAt)
+
+	_, err = pricer.latestRound(context.Background(), "bitcoin")
+	assert.NoError(t, err)
+
+	// An answer two hours old is stale for bitcoin but not for tether's longer heartbeat
+	mockCaller.On("CallContract", feedAddr, string(latest.ID)).Return(roundOut(now.Add(-2*time.Hour)), nil)
+
+	_, err = pricer.latestRound(context.Background(), "bitcoin")
+	assert.ErrorContains(t, err, "answer is stale")
+
+	_, err = pricer.latestRound(context.Background(), "tether")
+	assert.NoError(t, err)
+
+	_, err = pricer.latestRound(context.Background(), "dogecoin")
+	assert.Error(t, err)
+
+	// decimals() is read once per feed
+	mockCaller.AssertNumberOfCalls(t, "CallContract", 5)
+}
@@ -0,0 +1,233 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/sljivkov/dectek/config"
+)
+
+// Gas strategy names accepted in GAS_STRATEGY
+const (
+	GasFixed      = "fixed"      // Static tip and fee cap
+	GasPercentile = "percentile" // Tip from a reward percentile of recent blocks
+	GasBaseFee    = "basefee"    // Fee cap as a multiple of the current base fee
+)
+
+// defaultBumpPercent is the minimum fee increase nodes accept for a replacement
+const defaultBumpPercent = 10
+
+// GasStrategy suggests EIP-1559 fees for a price write
+type GasStrategy interface {
+	Fees(ctx context.Context) (tipCap, feeCap *big.Int, err error)
+}
+
+// GasBackend is the subset of the RPC client used to estimate fees
+type GasBackend interface {
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int,
+		rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+// NewGasStrategy builds the configured strategy, capped by GasMaxFeeGwei when set
+func NewGasStrategy(cfg config.Config, backend GasBackend) (GasStrategy, error) {
+	var strategy GasStrategy
+
+	switch cfg.GasStrategy {
+	case GasFixed:
+		strategy = &FixedGas{
+			TipCap: gweiToWei(cfg.GasTipGwei),
+			FeeCap: gweiToWei(cfg.GasFeeGwei),
+		}
+	case GasPercentile:
+		if cfg.GasPercentile < 0 || cfg.GasPercentile > 100 {
+			return nil, fmt.Errorf("gas percentile must be between 0 and 100, got %v", cfg.GasPercentile)
+		}
+
+		strategy = &PercentileGas{
+			backend:    backend,
+			blocks:     cfg.GasHistoryBlocks,
+			percentile: cfg.GasPercentile,
+		}
+	case GasBaseFee, "":
+		strategy = &BaseFeeGas{
+			backend:    backend,
+			multiplier: cfg.GasBaseFeeMultiplier,
+		}
+	default:
+		return nil, fmt.Errorf("unknown gas strategy %q", cfg.GasStrategy)
+	}
+
+	if cfg.GasMaxFeeGwei <= 0 {
+		return strategy, nil
+	}
+
+	return &cappedGas{strategy: strategy, maxFee: gweiToWei(cfg.GasMaxFeeGwei)}, nil
+}
+
+// FixedGas always suggests the same fees
+type FixedGas struct {
+	TipCap *big.Int
+	FeeCap *big.Int
+}
+
+// Fees returns the configured tip and fee cap
+func (f *FixedGas) Fees(context.Context) (*big.Int, *big.Int, error) {
+	if f.FeeCap.Cmp(f.TipCap) < 0 {
+		return nil, nil, fmt.Errorf("fee cap %s is below tip %s", f.FeeCap, f.TipCap)
+	}
+
+	return new(big.Int).Set(f.TipCap), new(big.Int).Set(f.FeeCap), nil
+}
+
+// PercentileGas uses the average reward percentile over recent blocks as the tip
+type PercentileGas struct {
+	backend    GasBackend
+	blocks     uint64
+	percentile float64
+}
+
+// Fees returns the percentile tip on top of twice the next block's base fee
+func (p *PercentileGas) Fees(ctx context.Context) (*big.Int, *big.Int, error) {
+	blocks := p.blocks
+	if blocks == 0 {
+		blocks = 1
+	}
+
+	history, err := p.backend.FeeHistory(ctx, blocks, nil, []float64{p.percentile})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch fee history: %w", err)
+	}
+
+	if len(history.BaseFee) == 0 || len(history.Reward) == 0 {
+		return nil, nil, fmt.Errorf("empty fee history")
+	}
+
+	tip := new(big.Int)
+	for _, rewards := range history.Reward {
+		if len(rewards) > 0 {
+			tip.Add(tip, rewards[0])
+		}
+	}
+
+	tip.Div(tip, big.NewInt(int64(len(history.Reward))))
+
+	// The last base fee is the one of the next block
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+
+	return tip, new(big.Int).Add(tip, new(big.Int).Mul(baseFee, big.NewInt(2))), nil
+}
+
+// BaseFeeGas multiplies the latest base fee and adds the node's suggested tip
+type BaseFeeGas struct {
+	backend    GasBackend
+	multiplier float64
+}
+
+// Fees returns the suggested tip and multiplier * base fee + tip as the fee cap
+func (b *BaseFeeGas) Fees(ctx context.Context) (*big.Int, *big.Int, error) {
+	tip, err := b.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to suggest gas tip: %w", err)
+	}
+
+	head, err := b.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch latest header: %w", err)
+	}
+
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("chain does not support EIP-1559")
+	}
+
+	multiplier := b.multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	feeCap := mulFloat(head.BaseFee, multiplier)
+
+	return tip, feeCap.Add(feeCap, tip), nil
+}
+
+// cappedGas limits the fee cap of another strategy to a ceiling
+type cappedGas struct {
+	strategy GasStrategy
+	maxFee   *big.Int
+}
+
+// Fees returns the wrapped strategy's fees clamped to the ceiling
+func (c *cappedGas) Fees(ctx context.Context) (*big.Int, *big.Int, error) {
+	tip, feeCap, err := c.strategy.Fees(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if feeCap.Cmp(c.maxFee) > 0 {
+		feeCap = new(big.Int).Set(c.maxFee)
+	}
+
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+
+	return tip, feeCap, nil
+}
+
+// bumpFees returns fees for replacing a stuck transaction: the larger of the current
+// suggestion and the old fees raised by percent. Replacing fails if that exceeds maxFee.
+func bumpFees(old *types.Transaction, tip, feeCap *big.Int, percent int, maxFee *big.Int) (*big.Int, *big.Int, error) {
+	if percent < defaultBumpPercent {
+		percent = defaultBumpPercent
+	}
+
+	minTip := bumpPercent(old.GasTipCap(), percent)
+	minFee := bumpPercent(old.GasFeeCap(), percent)
+
+	if tip == nil || tip.Cmp(minTip) < 0 {
+		tip = minTip
+	}
+
+	if feeCap == nil || feeCap.Cmp(minFee) < 0 {
+		feeCap = minFee
+	}
+
+	if maxFee != nil && maxFee.Sign() > 0 && minFee.Cmp(maxFee) > 0 {
+		return nil, nil, fmt.Errorf("replacement fee cap %s exceeds maximum %s", minFee, maxFee)
+	}
+
+	if maxFee != nil && maxFee.Sign() > 0 && feeCap.Cmp(maxFee) > 0 {
+		feeCap = new(big.Int).Set(maxFee)
+	}
+
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+
+	return tip, feeCap, nil
+}
+
+// bumpPercent returns v increased by percent, rounded up
+func bumpPercent(v *big.Int, percent int) *big.Int {
+	bumped := new(big.Int).Mul(v, big.NewInt(int64(100+percent)))
+	bumped.Add(bumped, big.NewInt(99))
+
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// mulFloat multiplies v by a float factor
+func mulFloat(v *big.Int, factor float64) *big.Int {
+	out, _ := new(big.Float).Mul(new(big.Float).SetInt(v), big.NewFloat(factor)).Int(nil)
+
+	return out
+}
+
+// gweiToWei converts a gwei amount to wei
+func gweiToWei(gwei float64) *big.Int {
+	return mulFloat(big.NewInt(1e9), gwei)
+}
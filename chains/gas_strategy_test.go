@@ -0,0 +1,129 @@
+package chains
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sljivkov/dectek/config"
+)
+
+// MockGasBackend implements GasBackend for testing
+type MockGasBackend struct {
+	mock.Mock
+}
+
+func (m *MockGasBackend) SuggestGasTipCap(_ context.Context) (*big.Int, error) {
+	args := m.Called()
+
+	return args.Get(0).(*big.Int), args.Error(1)
+}
+
+func (m *MockGasBackend) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	args := m.Called(number)
+
+	return args.Get(0).(*types.Header), args.Error(1)
+}
+
+func (m *MockGasBackend) FeeHistory(_ context.Context, blockCount uint64, lastBlock *big.Int,
+	rewardPercentiles []float64,
+) (*ethereum.FeeHistory, error) {
+	args := m.Called(blockCount, lastBlock, rewardPercentiles)
+
+	return args.Get(0).(*ethereum.FeeHistory), args.Error(1)
+}
+
+func gwei(v int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(v), big.NewInt(1e9))
+}
+
+func TestGasStrategies(t *testing.T) {
+	backend := new(MockGasBackend)
+	backend.On("SuggestGasTipCap").Return(gwei(2), nil)
+	backend.On("HeaderByNumber", (*big.Int)(nil)).Return(&types.Header{BaseFee: gwei(10)}, nil)
+	backend.On("FeeHistory", uint64(2), (*big.Int)(nil), []float64{50}).Return(&ethereum.FeeHistory{
+		Reward:  [][]*big.Int{{gwei(1)}, {gwei(3)}},
+		BaseFee: []*big.Int{gwei(8), gwei(9), gwei(12)},
+	}, nil)
+
+	tests := []struct {
+		name       string
+		cfg        config.Config
+		wantTip    *big.Int
+		wantFeeCap *big.Int
+		wantErr    bool
+	}{
+		{
+			name:       "fixed",
+			cfg:        config.Config{GasStrategy: GasFixed, GasTipGwei: 1.5, GasFeeGwei: 30},
+			wantTip:    big.NewInt(1_500_000_000),
+			wantFeeCap: gwei(30),
+		},
+		{
+			name:       "base fee multiplier",
+			cfg:        config.Config{GasStrategy: GasBaseFee, GasBaseFeeMultiplier: 3},
+			wantTip:    gwei(2),
+			wantFeeCap: gwei(32),
+		},
+		{
+			name:       "fee history percentile",
+			cfg:        config.Config{GasStrategy: GasPercentile, GasPercentile: 50, GasHistoryBlocks: 2},
+			wantTip:    gwei(2),
+			wantFeeCap: gwei(26),
+		},
+		{
+			name:       "capped",
+			cfg:        config.Config{GasStrategy: GasBaseFee, GasBaseFeeMultiplier: 3, GasMaxFeeGwei: 20},
+			wantTip:    gwei(2),
+			wantFeeCap: gwei(20),
+		},
+		{
+			name:    "unknown strategy",
+			cfg:     config.Config{GasStrategy: "auction"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := NewGasStrategy(tt.cfg, backend)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			tip, feeCap, err := strategy.Fees(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantTip, tip)
+			assert.Equal(t, tt.wantFeeCap, feeCap)
+		})
+	}
+}
+
+func TestBumpFees(t *testing.T) {
+	stuck := types.NewTx(&types.DynamicFeeTx{GasTipCap: gwei(2), GasFeeCap: gwei(20)})
+
+	// Suggestions below the minimum bump are raised to it
+	tip, feeCap, err := bumpFees(stuck, gwei(1), gwei(10), 15, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(2_300_000_000), tip)
+	assert.Equal(t, gwei(23), feeCap)
+
+	// Higher suggestions are kept but clamped to the ceiling
+	tip, feeCap, err = bumpFees(stuck, gwei(5), gwei(50), 15, gwei(30))
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(5), tip)
+	assert.Equal(t, gwei(30), feeCap)
+
+	// The replacement is refused when even the minimum bump exceeds the ceiling
+	_, _, err = bumpFees(stuck, nil, nil, 15, gwei(21))
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package chains
+
+import (
+	"log"
+	"time"
+
+	"github.com/sljivkov/dectek/contract"
+	"github.com/sljivkov/dectek/history"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// recordHistory stores an applied PriceChanged event in the price history. Failures are only logged,
+// the history never holds up event processing.
+func (s *SepoliaPriceFeed) recordHistory(event *contract.ContractPriceChanged, price pricefeed.Price) {
+	if s.priceHistory == nil {
+		return
+	}
+
+	record := eventRecord(event)
+	record.Price = price.USD
+
+	if err := s.priceHistory.Add(record); err != nil {
+		log.Printf("⚠️ Failed to record %s event in price history: %v", event.Symbol, err)
+	}
+}
+
+// forgetHistory removes the record of a PriceChanged event whose log was removed by a reorg,
+// so the history only shows prices that were actually published
+func (s *SepoliaPriceFeed) forgetHistory(event *contract.ContractPriceChanged) {
+	if s.priceHistory == nil {
+		return
+	}
+
+	if err := s.priceHistory.Remove(eventRecord(event)); err != nil {
+		log.Printf("⚠️ Failed to remove %s event from price history: %v", event.Symbol, err)
+	}
+}
+
+// eventRecord returns the history record of an event without its price
+func eventRecord(event *contract.ContractPriceChanged) history.Record {
+	return history.Record{
+		Source:      history.SourceChain,
+		Symbol:      event.Symbol,
+		Time:        time.Unix(event.Timestamp.Int64(), 0),
+		BlockNumber: event.Raw.BlockNumber,
+		TxHash:      event.Raw.TxHash.Hex(),
+		LogIndex:    event.Raw.Index,
+	}
+}
@@ -0,0 +1,86 @@
+package chains
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/history"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+func TestRecordHistory(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	assert.NoError(t, err)
+
+	defer store.Close()
+
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+
+	cfg := testConfig()
+	cfg.BackfillFromBlock = 100
+
+	mockBackend.On("BlockNumber").Return(uint64(110), nil)
+
+	// Two processes without saved state replay the same range
+	for range 2 {
+		feed := &SepoliaPriceFeed{
+			cfg:           cfg,
+			contract:      mockContract,
+			backend:       mockBackend,
+			onChainPrices: make(map[string]*big.Int),
+			priceHistory:  store,
+		}
+
+		mockContract.On("FilterPriceChanged", uint64(100), uint64(110)).Return(priceChangedIterator(t,
+			priceChangedLog{block: 105, symbol: "bitcoin", price: 3000000, timestamp: 1_700_000_000},
+			priceChangedLog{block: 105, symbol: "bitcoin", price: 3000500, timestamp: 1_700_000_000, index: 1},
+		), nil).Once()
+
+		assert.NoError(t, feed.Backfill(context.Background(), make(chan pricefeed.Price, 10)))
+	}
+
+	at := time.Unix(1_700_000_000, 0)
+
+	records, err := store.Query(history.SourceChain, "bitcoin", at, at)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "30000", records[0].Price.String())
+	assert.Equal(t, "30005", records[1].Price.String())
+	assert.Equal(t, uint64(105), records[1].BlockNumber)
+	assert.Equal(t, uint(1), records[1].LogIndex)
+	assert.NotEmpty(t, records[1].TxHash)
+}
+
+func TestForgetHistory(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	assert.NoError(t, err)
+
+	defer store.Close()
+
+	feed := &SepoliaPriceFeed{
+		cfg:           testConfig(),
+		onChainPrices: make(map[string]*big.Int),
+		priceHistory:  store,
+	}
+
+	ctx := context.Background()
+	out := make(chan pricefeed.Price, 10)
+
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(10, 3000000, false), out))
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(11, 3100000, false), out))
+
+	// The reorg removes the event of block 11 from the history as well
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(11, 3100000, true), out))
+
+	records, err := store.Query(history.SourceChain, "bitcoin", time.Time{}, time.Unix(1_800_000_000, 0))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, uint64(10), records[0].BlockNumber)
+	assert.Equal(t, "30000", records[0].Price.String())
+}
@@ -0,0 +1,63 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NonceSource reads the next pending nonce of an account from the node
+type NonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// nonceManager hands out nonces locally so back-to-back writes don't race on
+// the node's pending nonce. After a failed send or a dropped transaction it is
+// resynced from the node, which also closes any gap left by the lost nonce.
+type nonceManager struct {
+	source  NonceSource
+	account common.Address
+
+	mu     sync.Mutex
+	next   uint64
+	synced bool
+}
+
+// newNonceManager creates a nonce manager for account, synced lazily on first use
+func newNonceManager(source NonceSource, account common.Address) *nonceManager {
+	return &nonceManager{
+		source:  source,
+		account: account,
+	}
+}
+
+// Next reserves and returns the next nonce, syncing from the node when needed
+func (n *nonceManager) Next(ctx context.Context) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.synced {
+		nonce, err := n.source.PendingNonceAt(ctx, n.account)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch pending nonce: %w", err)
+		}
+
+		n.next = nonce
+		n.synced = true
+	}
+
+	nonce := n.next
+	n.next++
+
+	return nonce, nil
+}
+
+// Reset drops the local nonce so the next allocation resyncs from the node
+func (n *nonceManager) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.synced = false
+}
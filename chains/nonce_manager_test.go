@@ -0,0 +1,48 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonceManager(t *testing.T) {
+	mockBackend := new(MockTxBackend)
+	account := common.HexToAddress("0x1")
+	nonces := newNonceManager(mockBackend, account)
+
+	mockBackend.On("PendingNonceAt", account).Return(uint64(5), nil).Once()
+
+	// Nonces are allocated locally after the first sync
+	for want := uint64(5); want < 8; want++ {
+		nonce, err := nonces.Next(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, want, nonce)
+	}
+
+	// After a reset the node is the source of truth again, filling the gap
+	mockBackend.On("PendingNonceAt", account).Return(uint64(6), nil).Once()
+	nonces.Reset()
+
+	nonce, err := nonces.Next(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), nonce)
+
+	// Sync errors are surfaced and retried on the next allocation
+	mockBackend.On("PendingNonceAt", account).Return(uint64(0), fmt.Errorf("rpc down")).Once()
+	nonces.Reset()
+
+	_, err = nonces.Next(context.Background())
+	assert.Error(t, err)
+
+	mockBackend.On("PendingNonceAt", account).Return(uint64(7), nil).Once()
+
+	nonce, err = nonces.Next(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), nonce)
+
+	mockBackend.AssertExpectations(t)
+}
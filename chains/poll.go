@@ -0,0 +1,193 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/sljivkov/dectek/contract"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// Polling defaults used when EVENT_POLL_INTERVAL or EVENT_POLL_WINDOW are not set
+const (
+	defaultEventPollInterval = 12 * time.Second
+	defaultEventPollWindow   = 20
+)
+
+// logKey identifies a PriceChanged log across repeated queries. The block hash tells a transaction
+// mined again after a reorg apart from its earlier log.
+type logKey struct {
+	blockHash common.Hash
+	txHash    common.Hash
+	index     uint
+}
+
+// usesPolling reports whether the RPC URL is served over plain HTTP, which cannot carry log subscriptions
+func usesPolling(rpcURL string) bool {
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return false
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+
+	return scheme == "http" || scheme == "https"
+}
+
+// poll catches up on missed blocks like listen, then queries PriceChanged logs every EventPollInterval
+// until a query fails or ctx is canceled. Each query also covers the last EventPollWindow blocks so
+// logs a node indexed late are not missed, and logs already handled are skipped by block hash, tx hash
+// and index. It reports whether the catch-up succeeded.
+func (s *SepoliaPriceFeed) poll(ctx context.Context, out chan<- pricefeed.Price) (bool, error) {
+	caughtUp, err := s.catchUp(ctx, out)
+	if err != nil {
+		return false, err
+	}
+
+	s.dropPendingThrough(caughtUp)
+
+	// Events still awaiting confirmations were handled before the restart
+	seen := make(map[logKey]uint64)
+	for _, event := range s.pending {
+		seen[keyOf(event)] = event.Raw.BlockNumber
+	}
+
+	interval := s.cfg.EventPollInterval
+	if interval <= 0 {
+		interval = defaultEventPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("📡 Polling for PriceChanged events every %s...", interval)
+
+	next := caughtUp + 1
+
+	for {
+		select {
+		case <-ticker.C:
+			polled, err := s.pollLogs(ctx, caughtUp, next, seen, out)
+			if err != nil {
+				return true, err
+			}
+
+			next = polled + 1
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+}
+
+// pollLogs handles the new PriceChanged logs from next up to the head, never going below floor,
+// which the catch-up already covered. It returns the last block queried.
+// Queried logs never come back removed, so the query also covers the events awaiting confirmations,
+// and those it no longer returns were reorganized away and are dropped before they are applied.
+func (s *SepoliaPriceFeed) pollLogs(ctx context.Context, floor, next uint64, seen map[logKey]uint64,
+	out chan<- pricefeed.Price,
+) (uint64, error) {
+	head, err := s.backend.BlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch head block: %w", err)
+	}
+
+	if head < next {
+		return next - 1, s.releaseConfirmed(ctx, out)
+	}
+
+	window := s.cfg.EventPollWindow
+	if window == 0 {
+		window = defaultEventPollWindow
+	}
+
+	from := next
+	if head >= window {
+		from = max(min(from, head-window+1), floor+1)
+	}
+
+	if len(s.pending) > 0 {
+		from = max(min(from, s.pending[0].Raw.BlockNumber), floor+1)
+	}
+
+	pageSize := s.cfg.BackfillPageSize
+	if pageSize == 0 {
+		pageSize = defaultBackfillPageSize
+	}
+
+	// A large gap is worked through one page per poll
+	to := min(head, from+pageSize-1)
+
+	it, err := s.contract.FilterPriceChanged(&bind.FilterOpts{Start: from, End: &to, Context: ctx})
+	if err != nil {
+		return 0, fmt.Errorf("failed to filter PriceChanged events in blocks %d-%d: %w", from, to, err)
+	}
+
+	defer it.Close()
+
+	found := make(map[logKey]bool)
+
+	for it.Next() {
+		event := it.Event
+		if event.Raw.Removed {
+			continue
+		}
+
+		key := keyOf(event)
+		found[key] = true
+
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = event.Raw.BlockNumber
+
+		if err := s.handleEvent(ctx, event, out); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := it.Error(); err != nil {
+		return 0, fmt.Errorf("failed to read PriceChanged events in blocks %d-%d: %w", from, to, err)
+	}
+
+	s.dropNonCanonical(from, to, found, seen)
+
+	// Blocks below this query will not be queried again
+	for key, block := range seen {
+		if block < from {
+			delete(seen, key)
+		}
+	}
+
+	return max(to, next-1), s.releaseConfirmed(ctx, out)
+}
+
+// dropNonCanonical removes the pending events in blocks from-to whose logs the query did not return
+func (s *SepoliaPriceFeed) dropNonCanonical(from, to uint64, found map[logKey]bool, seen map[logKey]uint64) {
+	kept := s.pending[:0]
+
+	for _, p := range s.pending {
+		if p.Raw.BlockNumber < from || p.Raw.BlockNumber > to || found[keyOf(p)] {
+			kept = append(kept, p)
+
+			continue
+		}
+
+		delete(seen, keyOf(p))
+		log.Printf("🔙 Dropped unconfirmed %s event no longer in block %d", p.Symbol, p.Raw.BlockNumber)
+	}
+
+	s.pending = kept
+}
+
+// keyOf returns the deduplication key of an event's log
+func keyOf(event *contract.ContractPriceChanged) logKey {
+	return logKey{blockHash: event.Raw.BlockHash, txHash: event.Raw.TxHash, index: event.Raw.Index}
+}
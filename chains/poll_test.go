@@ -0,0 +1,220 @@
+package chains
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+func TestUsesPolling(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "https://eth-sepolia.g.alchemy.com/v2/key", want: true},
+		{url: "HTTP://localhost:8545", want: true},
+		{url: "wss://eth-sepolia.g.alchemy.com/v2/key", want: false},
+		{url: "ws://localhost:8546", want: false},
+		{url: "/tmp/geth.ipc", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, usesPolling(tt.url))
+		})
+	}
+}
+
+func TestPollLogs(t *testing.T) {
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+
+	cfg := testConfig()
+	cfg.EventPollWindow = 5
+
+	feed := &SepoliaPriceFeed{
+		cfg:           cfg,
+		contract:      mockContract,
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+		lastBlock:     100,
+	}
+
+	ctx := context.Background()
+	out := make(chan pricefeed.Price, 10)
+	seen := make(map[logKey]uint64)
+
+	// The first poll starts right after the catch-up
+	mockBackend.On("BlockNumber").Return(uint64(110), nil).Once()
+	mockContract.On("FilterPriceChanged", uint64(101), uint64(110)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 105, symbol: "bitcoin", price: 3100000, timestamp: 1_700_000_100},
+	), nil).Once()
+
+	polled, err := feed.pollLogs(ctx, 100, 101, seen, out)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(110), polled)
+
+	// Later polls also cover the last five blocks
+	mockBackend.On("BlockNumber").Return(uint64(112), nil).Once()
+	mockContract.On("FilterPriceChanged", uint64(108), uint64(112)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 109, symbol: "ethereum", price: 200012, timestamp: 1_700_000_200},
+	), nil).Once()
+
+	polled, err = feed.pollLogs(ctx, 100, 111, seen, out)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(112), polled)
+
+	// Without a new block there is nothing to query
+	mockBackend.On("BlockNumber").Return(uint64(112), nil).Once()
+
+	polled, err = feed.pollLogs(ctx, 100, 113, seen, out)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(112), polled)
+
+	// A log returned again by the overlapping window is skipped
+	mockBackend.On("BlockNumber").Return(uint64(113), nil).Once()
+	mockContract.On("FilterPriceChanged", uint64(109), uint64(113)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 109, symbol: "ethereum", price: 200012, timestamp: 1_700_000_200},
+		priceChangedLog{block: 109, symbol: "ethereum", price: 200500, timestamp: 1_700_000_250, index: 1},
+		priceChangedLog{block: 113, symbol: "bitcoin", price: 3200000, timestamp: 1_700_000_300},
+	), nil).Once()
+
+	polled, err = feed.pollLogs(ctx, 100, 113, seen, out)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(113), polled)
+
+	close(out)
+
+	var received []string
+	for p := range out {
+		received = append(received, p.Symbol+"="+p.USD.String())
+	}
+
+	assert.Equal(t, []string{"bitcoin=31000", "ethereum=2000.12", "ethereum=2005", "bitcoin=32000"}, received)
+	assert.Equal(t, uint64(113), feed.LastBlock())
+
+	// Logs below the last window are forgotten
+	assert.Len(t, seen, 3)
+	mockContract.AssertExpectations(t)
+	mockBackend.AssertExpectations(t)
+}
+
+func TestPollLogs_DropsReorgedPending(t *testing.T) {
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+
+	cfg := testConfig()
+	cfg.EventPollWindow = 2
+	cfg.EventConfirmations = 3
+
+	feed := &SepoliaPriceFeed{
+		cfg:           cfg,
+		contract:      mockContract,
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+		lastBlock:     100,
+	}
+
+	ctx := context.Background()
+	out := make(chan pricefeed.Price, 10)
+	seen := make(map[logKey]uint64)
+
+	// A log in block 109 waits for its confirmations
+	mockBackend.On("BlockNumber").Return(uint64(110), nil).Twice()
+	mockContract.On("FilterPriceChanged", uint64(101), uint64(110)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 109, symbol: "bitcoin", price: 3100000, timestamp: 1_700_000_100},
+	), nil).Once()
+
+	polled, err := feed.pollLogs(ctx, 100, 101, seen, out)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(110), polled)
+	assert.Len(t, feed.pending, 1)
+
+	// The next query reaches back to the pending block and no longer finds the log
+	mockBackend.On("BlockNumber").Return(uint64(112), nil).Twice()
+	mockContract.On("FilterPriceChanged", uint64(109), uint64(112)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 111, symbol: "bitcoin", price: 3200000, timestamp: 1_700_000_200},
+	), nil).Once()
+
+	polled, err = feed.pollLogs(ctx, 100, 111, seen, out)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(112), polled)
+
+	// Once confirmed, only the canonical log is applied
+	mockBackend.On("BlockNumber").Return(uint64(114), nil).Twice()
+	mockContract.On("FilterPriceChanged", uint64(111), uint64(114)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 111, symbol: "bitcoin", price: 3200000, timestamp: 1_700_000_200},
+	), nil).Once()
+
+	polled, err = feed.pollLogs(ctx, 100, 113, seen, out)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(114), polled)
+
+	close(out)
+
+	var received []string
+	for p := range out {
+		received = append(received, p.Symbol+"="+p.USD.String())
+	}
+
+	assert.Equal(t, []string{"bitcoin=32000"}, received)
+	assert.Empty(t, feed.pending)
+	assert.Equal(t, uint64(111), feed.LastBlock())
+	mockContract.AssertExpectations(t)
+	mockBackend.AssertExpectations(t)
+}
+
+func TestListenOnChainPriceUpdate_Polling(t *testing.T) {
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+
+	cfg := testConfig()
+	cfg.Alchemy = "https://eth-sepolia.g.alchemy.com/v2/key"
+	cfg.EventPollInterval = 10 * time.Millisecond
+
+	feed := &SepoliaPriceFeed{
+		cfg:           cfg,
+		contract:      mockContract,
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+		lastBlock:     100,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The catch-up finds no new block, the first poll finds an event in block 102
+	mockBackend.On("BlockNumber").Return(uint64(100), nil).Once()
+	mockBackend.On("BlockNumber").Return(uint64(102), nil)
+	mockContract.On("FilterPriceChanged", uint64(101), uint64(102)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 102, symbol: "bitcoin", price: 3100000, timestamp: 1_700_000_100},
+	), nil).Once()
+
+	out := make(chan pricefeed.Price)
+
+	go feed.ListenOnChainPriceUpdate(ctx, out)
+
+	select {
+	case price := <-out:
+		assert.Equal(t, "bitcoin", price.Symbol)
+		assert.Equal(t, "31000", price.USD.String())
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for price update")
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-out:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("listener did not stop")
+	}
+
+	mockContract.AssertNotCalled(t, "WatchPriceChanged")
+}
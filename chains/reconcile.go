@@ -0,0 +1,140 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// Divergence is a difference between the event-derived cache and the contract's storage
+type Divergence struct {
+	Symbol     string            `json:"symbol"`
+	Cached     pricefeed.Decimal `json:"cached"` // 0 when nothing was cached
+	Stored     pricefeed.Decimal `json:"stored"`
+	DetectedAt time.Time         `json:"detectedAt"`
+}
+
+// Reconcile reads the stored price of every configured token with Get at the confirmed head and
+// makes it the cached on-chain price, so storage of blocks that may still be reorganized away never
+// enters the cache. Tokens whose cache disagreed with storage are logged and kept in Divergences
+// until a later reconcile finds them in agreement. Tokens that fail to read keep their cache.
+func (s *SepoliaPriceFeed) Reconcile(ctx context.Context) error {
+	head, err := s.confirmedHead(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, symbol := range s.cfg.TokenList() {
+		opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(head)}
+
+		stored, err := s.contract.Get(opts, symbol)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read stored %s price: %w", symbol, err))
+
+			continue
+		}
+
+		s.reconcileToken(symbol, stored, head)
+	}
+
+	s.saveState()
+
+	return errors.Join(errs...)
+}
+
+// reconcileToken replaces the cached price of symbol with the one stored at block, recording any
+// divergence. A price without a known PriceChanged timestamp starts its heartbeat at the reconcile.
+// The cache is left alone if it already holds events newer than block.
+func (s *SepoliaPriceFeed) reconcileToken(symbol string, stored *big.Int, block uint64) {
+	s.pricesMu.Lock()
+
+	if s.lastBlock > block {
+		s.pricesMu.Unlock()
+
+		return
+	}
+
+	cached := s.onChainPrices[symbol]
+	s.onChainPrices[symbol] = new(big.Int).Set(stored)
+
+	// The age of the stored price is unknown, so its heartbeat conservatively starts now
+	if _, ok := s.updatedAt[symbol]; !ok {
+		if s.updatedAt == nil {
+			s.updatedAt = make(map[string]time.Time)
+		}
+
+		s.updatedAt[symbol] = time.Now()
+	}
+
+	s.pricesMu.Unlock()
+
+	s.divergencesMu.Lock()
+	defer s.divergencesMu.Unlock()
+
+	// An empty cache, e.g. after a restart without backfill, is only populated
+	if cached == nil || cached.Sign() == 0 || cached.Cmp(stored) == 0 {
+		delete(s.divergences, symbol)
+
+		return
+	}
+
+	decimals := s.cfg.Token(symbol).Decimals
+	divergence := Divergence{
+		Symbol:     symbol,
+		Cached:     pricefeed.DecimalFromUnits(cached, decimals),
+		Stored:     pricefeed.DecimalFromUnits(stored, decimals),
+		DetectedAt: time.Now(),
+	}
+
+	log.Printf("⚠️ %s cache diverged from contract storage: cached %s, stored %s",
+		symbol, divergence.Cached, divergence.Stored)
+
+	if s.divergences == nil {
+		s.divergences = make(map[string]Divergence)
+	}
+
+	s.divergences[symbol] = divergence
+}
+
+// Divergences returns the tokens whose cache disagreed with contract storage at the last reconcile
+func (s *SepoliaPriceFeed) Divergences() map[string]Divergence {
+	s.divergencesMu.Lock()
+	defer s.divergencesMu.Unlock()
+
+	divergences := make(map[string]Divergence, len(s.divergences))
+	for symbol, d := range s.divergences {
+		divergences[symbol] = d
+	}
+
+	return divergences
+}
+
+// RunReconciler reconciles the cache with contract storage every ReconcileInterval until ctx is canceled
+func (s *SepoliaPriceFeed) RunReconciler(ctx context.Context) {
+	if s.cfg.ReconcileInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Reconcile(ctx); err != nil {
+				log.Printf("❌ Reconcile failed: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReconcile(t *testing.T) {
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+
+	cfg := testConfig()
+	cfg.Tokens = "bitcoin,ethereum,tether,dogecoin"
+	cfg.EventConfirmations = 3
+	cfg.Heartbeat = time.Hour
+
+	feed := &SepoliaPriceFeed{
+		cfg:      cfg,
+		contract: mockContract,
+		backend:  mockBackend,
+		onChainPrices: map[string]*big.Int{
+			"bitcoin":  big.NewInt(3000000),
+			"ethereum": big.NewInt(199000),
+		},
+		updatedAt: map[string]time.Time{
+			"bitcoin": time.Unix(1_700_000_000, 0),
+		},
+	}
+
+	mockBackend.On("BlockNumber").Return(uint64(500), nil)
+	mockContract.On("Get", mock.Anything, "bitcoin").Return(big.NewInt(3000000), nil)
+	mockContract.On("Get", mock.Anything, "ethereum").Return(big.NewInt(200012), nil)
+	mockContract.On("Get", mock.Anything, "tether").Return(big.NewInt(100), nil)
+	mockContract.On("Get", mock.Anything, "dogecoin").Return((*big.Int)(nil), errors.New("rpc down"))
+
+	err := feed.Reconcile(context.Background())
+	assert.ErrorContains(t, err, "dogecoin")
+
+	// Storage wins, missing entries are populated
+	prices := feed.OnChainPrices()
+	assert.Equal(t, "30000", prices["bitcoin"].String())
+	assert.Equal(t, "2000.12", prices["ethereum"].String())
+	assert.Equal(t, "1", prices["tether"].String())
+	assert.NotContains(t, prices, "dogecoin")
+
+	// Known event timestamps are kept, prices of unknown age start their heartbeat now
+	assert.Equal(t, int64(1_700_000_000), feed.updatedAt["bitcoin"].Unix())
+	assert.WithinDuration(t, time.Now(), feed.updatedAt["tether"], time.Minute)
+	assert.False(t, feed.heartbeatDue("tether"))
+	assert.NotContains(t, feed.updatedAt, "dogecoin")
+
+	// Only the cached price that disagreed is reported
+	divergences := feed.Divergences()
+	assert.Len(t, divergences, 1)
+	assert.Equal(t, "1990", divergences["ethereum"].Cached.String())
+	assert.Equal(t, "2000.12", divergences["ethereum"].Stored.String())
+
+	// Storage is read at the confirmed head
+	opts := mockContract.Calls[0].Arguments.Get(0).(*bind.CallOpts)
+	assert.Equal(t, big.NewInt(497), opts.BlockNumber)
+
+	// Agreement clears the divergence
+	err = feed.Reconcile(context.Background())
+	assert.ErrorContains(t, err, "dogecoin")
+	assert.Empty(t, feed.Divergences())
+
+	// A cache holding events newer than the read block is left alone
+	feed.setOnChainPrice("bitcoin", big.NewInt(3100000))
+	feed.setLastBlock(501)
+
+	err = feed.Reconcile(context.Background())
+	assert.ErrorContains(t, err, "dogecoin")
+	assert.Equal(t, "31000", feed.OnChainPrices()["bitcoin"].String())
+	assert.Empty(t, feed.Divergences())
+}
@@ -0,0 +1,135 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// Policies for writes when no reference price is available, accepted in REFERENCE_UNAVAILABLE
+const (
+	ReferenceSkip  = "skip"  // Do not write the price
+	ReferenceAllow = "allow" // Write without the sanity band check
+)
+
+// defaultAPIReferenceTTL is how long an APIReference reuses fetched prices when its provider has no interval
+const defaultAPIReferenceTTL = 10 * time.Second
+
+// ErrNoReference is returned when no reference price could be obtained
+var ErrNoReference = errors.New("no reference price available")
+
+// ReferencePricer provides an independent USD price that writes are sanity checked against
+type ReferencePricer interface {
+	// Name identifies the reference in logs and errors
+	Name() string
+	// ReferencePrice returns the current reference price of a token
+	ReferencePrice(ctx context.Context, symbol string) (pricefeed.Decimal, error)
+}
+
+// FallbackReference tries its references in order and returns the first available price
+type FallbackReference struct {
+	references []ReferencePricer
+}
+
+// NewFallbackReference creates a reference that falls back through the given references in order
+func NewFallbackReference(references ...ReferencePricer) *FallbackReference {
+	return &FallbackReference{references: references}
+}
+
+// Name returns the names of the references in fallback order
+func (f *FallbackReference) Name() string {
+	names := make([]string, len(f.references))
+	for i, r := range f.references {
+		names[i] = r.Name()
+	}
+
+	return strings.Join(names, ">")
+}
+
+// ReferencePrice returns the price of the first reference that has one, or an error
+// wrapping ErrNoReference with every reference's failure
+func (f *FallbackReference) ReferencePrice(ctx context.Context, symbol string) (pricefeed.Decimal, error) {
+	var errs []error
+
+	for _, r := range f.references {
+		price, err := r.ReferencePrice(ctx, symbol)
+		if err == nil {
+			return price, nil
+		}
+
+		log.Printf("⚠️ %s reference unavailable for %s: %v", r.Name(), symbol, err)
+
+		errs = append(errs, fmt.Errorf("%s: %w", r.Name(), err))
+	}
+
+	return pricefeed.Decimal{}, fmt.Errorf("%w for %s: %w", ErrNoReference, symbol, errors.Join(errs...))
+}
+
+// PriceFetcher fetches current prices on demand, implemented by the REST API providers
+type PriceFetcher interface {
+	FetchPrices(ctx context.Context) ([]pricefeed.Price, error)
+	// Interval is the provider's polling interval, the shortest time between requests its rate limit allows
+	Interval() time.Duration
+}
+
+// APIReference uses a secondary price API as the reference
+type APIReference struct {
+	name    string
+	fetcher PriceFetcher
+	ttl     time.Duration // how long fetched prices are reused
+	now     func() time.Time
+
+	mu        sync.Mutex
+	prices    map[string]pricefeed.Decimal
+	fetchedAt time.Time
+}
+
+// NewAPIReference creates a reference backed by an API provider. Fetched prices are reused for the
+// provider's polling interval so the reference stays within the same rate limit as the polled source.
+func NewAPIReference(name string, fetcher PriceFetcher) *APIReference {
+	ttl := fetcher.Interval()
+	if ttl <= 0 {
+		ttl = defaultAPIReferenceTTL
+	}
+
+	return &APIReference{name: name, fetcher: fetcher, ttl: ttl, now: time.Now}
+}
+
+// Name returns the API name
+func (a *APIReference) Name() string {
+	return a.name
+}
+
+// ReferencePrice returns the API price of a token. Prices are fetched for all tokens at once
+// and reused for the provider's polling interval so that a write cycle makes a single request.
+func (a *APIReference) ReferencePrice(ctx context.Context, symbol string) (pricefeed.Decimal, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.prices == nil || a.now().Sub(a.fetchedAt) > a.ttl {
+		data, err := a.fetcher.FetchPrices(ctx)
+		if err != nil {
+			return pricefeed.Decimal{}, err
+		}
+
+		a.prices = make(map[string]pricefeed.Decimal, len(data))
+		for _, p := range data {
+			a.prices[p.Symbol] = p.USD
+		}
+
+		a.fetchedAt = a.now()
+	}
+
+	price, ok := a.prices[symbol]
+	if !ok {
+		return pricefeed.Decimal{}, fmt.Errorf("no %s price for %s", a.name, symbol)
+	}
+
+	return price, nil
+}
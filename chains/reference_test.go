@@ -0,0 +1,103 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// MockPriceFetcher implements PriceFetcher for testing
+type MockPriceFetcher struct {
+	mock.Mock
+}
+
+func (m *MockPriceFetcher) FetchPrices(_ context.Context) ([]pricefeed.Price, error) {
+	args := m.Called()
+
+	return args.Get(0).([]pricefeed.Price), args.Error(1)
+}
+
+func (m *MockPriceFetcher) Interval() time.Duration {
+	args := m.Called()
+
+	return args.Get(0).(time.Duration)
+}
+
+func TestFallbackReference(t *testing.T) {
+	primary := new(MockReferencePricer)
+	secondary := new(MockReferencePricer)
+
+	primary.On("ReferencePrice", "bitcoin").Return(pricefeed.MustDecimal("30000.5"), nil)
+	primary.On("ReferencePrice", "ethereum").Return(pricefeed.Decimal{}, errors.New("stale"))
+	primary.On("ReferencePrice", "tether").Return(pricefeed.Decimal{}, errors.New("stale"))
+	secondary.On("ReferencePrice", "ethereum").Return(pricefeed.MustDecimal("2000.25"), nil)
+	secondary.On("ReferencePrice", "tether").Return(pricefeed.Decimal{}, errors.New("no pool"))
+
+	reference := NewFallbackReference(primary, secondary)
+	ctx := context.Background()
+
+	// The first reference wins when available
+	price, err := reference.ReferencePrice(ctx, "bitcoin")
+	assert.NoError(t, err)
+	assert.Equal(t, "30000.5", price.String())
+	secondary.AssertNotCalled(t, "ReferencePrice", "bitcoin")
+
+	// Later references are tried in order
+	price, err = reference.ReferencePrice(ctx, "ethereum")
+	assert.NoError(t, err)
+	assert.Equal(t, "2000.25", price.String())
+
+	// Every failure is reported
+	_, err = reference.ReferencePrice(ctx, "tether")
+	assert.ErrorIs(t, err, ErrNoReference)
+	assert.ErrorContains(t, err, "stale")
+	assert.ErrorContains(t, err, "no pool")
+}
+
+func TestAPIReference(t *testing.T) {
+	fetcher := new(MockPriceFetcher)
+	fetcher.On("FetchPrices").Return([]pricefeed.Price{
+		{Symbol: "bitcoin", USD: pricefeed.MustDecimal("30000.12")},
+		{Symbol: "ethereum", USD: pricefeed.MustDecimal("2000")},
+	}, nil)
+	fetcher.On("Interval").Return(time.Minute)
+
+	now := time.Unix(1_700_000_000, 0)
+	reference := NewAPIReference("kraken", fetcher)
+	reference.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	price, err := reference.ReferencePrice(ctx, "bitcoin")
+	assert.NoError(t, err)
+	assert.Equal(t, "30000.12", price.String())
+
+	// Other tokens reuse the same response
+	price, err = reference.ReferencePrice(ctx, "ethereum")
+	assert.NoError(t, err)
+	assert.Equal(t, "2000", price.String())
+	fetcher.AssertNumberOfCalls(t, "FetchPrices", 1)
+
+	_, err = reference.ReferencePrice(ctx, "dogecoin")
+	assert.Error(t, err)
+
+	// Prices are reused for the provider's polling interval
+	now = now.Add(30 * time.Second)
+
+	_, err = reference.ReferencePrice(ctx, "bitcoin")
+	assert.NoError(t, err)
+	fetcher.AssertNumberOfCalls(t, "FetchPrices", 1)
+
+	// Prices are refetched once they expire
+	now = now.Add(31 * time.Second)
+
+	_, err = reference.ReferencePrice(ctx, "bitcoin")
+	assert.NoError(t, err)
+	fetcher.AssertNumberOfCalls(t, "FetchPrices", 2)
+}
@@ -0,0 +1,296 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/sljivkov/dectek/contract"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// maxAppliedEvents bounds how many applied events are remembered per token for rollbacks
+const maxAppliedEvents = 64
+
+// appliedEvent is a PriceChanged event applied to the cache, kept to undo it on a reorg
+type appliedEvent struct {
+	block     uint64
+	blockHash common.Hash
+	txHash    common.Hash
+	index     uint
+	prevPrice *big.Int // cached price before the event, nil if there was none
+	prevAt    time.Time
+}
+
+// recordApplied remembers the cached value an event is about to replace. Callers hold pricesMu.
+func (sp *SepoliaPriceFeed) recordApplied(event *contract.ContractPriceChanged) {
+	if sp.applied == nil {
+		sp.applied = make(map[string][]appliedEvent)
+	}
+
+	applied := append(sp.applied[event.Symbol], appliedEvent{
+		block:     event.Raw.BlockNumber,
+		blockHash: event.Raw.BlockHash,
+		txHash:    event.Raw.TxHash,
+		index:     event.Raw.Index,
+		prevPrice: sp.onChainPrices[event.Symbol],
+		prevAt:    sp.updatedAt[event.Symbol],
+	})
+
+	if len(applied) > maxAppliedEvents {
+		applied = applied[len(applied)-maxAppliedEvents:]
+	}
+
+	sp.applied[event.Symbol] = applied
+}
+
+// rollbackPriceChanged undoes an applied event whose log was removed by a reorg. It returns the
+// restored price and true when the token still has a cached price afterwards.
+func (sp *SepoliaPriceFeed) rollbackPriceChanged(event *contract.ContractPriceChanged) (pricefeed.Price, bool) {
+	sp.pricesMu.Lock()
+	defer sp.pricesMu.Unlock()
+
+	applied := sp.applied[event.Symbol]
+
+	i := len(applied) - 1
+	for ; i >= 0; i-- {
+		e := applied[i]
+		if e.blockHash == event.Raw.BlockHash && e.txHash == event.Raw.TxHash && e.index == event.Raw.Index {
+			break
+		}
+	}
+
+	if i < 0 {
+		log.Printf("⚠️ Removed %s event in block %d was never applied", event.Symbol, event.Raw.BlockNumber)
+
+		return pricefeed.Price{}, false
+	}
+
+	removed := applied[i]
+
+	if i == len(applied)-1 {
+		// The removed event set the current value, restore the one it replaced
+		if removed.prevPrice == nil {
+			delete(sp.onChainPrices, event.Symbol)
+			delete(sp.updatedAt, event.Symbol)
+		} else {
+			sp.onChainPrices[event.Symbol] = removed.prevPrice
+			sp.updatedAt[event.Symbol] = removed.prevAt
+		}
+	} else {
+		// A later event replaced it, which now replaces the value from before the removed one
+		applied[i+1].prevPrice = removed.prevPrice
+		applied[i+1].prevAt = removed.prevAt
+	}
+
+	sp.applied[event.Symbol] = append(applied[:i], applied[i+1:]...)
+
+	// Events of the replacement block must be caught up again after a reconnect
+	if removed.block > 0 {
+		sp.lastBlock = min(sp.lastBlock, removed.block-1)
+	}
+
+	current, ok := sp.onChainPrices[event.Symbol]
+
+	log.Printf("🔙 Rolled back %s event in block %d (%s), cached price now %v",
+		event.Symbol, removed.block, removed.blockHash.Hex(), current)
+
+	if !ok {
+		return pricefeed.Price{}, false
+	}
+
+	return pricefeed.Price{
+		Symbol: event.Symbol,
+		USD:    pricefeed.DecimalFromUnits(current, sp.cfg.Token(event.Symbol).Decimals),
+	}, true
+}
+
+// handleEvent applies a live PriceChanged event, or rolls it back when its log was removed.
+// With EventConfirmations set, new events wait in pending and removed ones are dropped from it.
+func (s *SepoliaPriceFeed) handleEvent(ctx context.Context, event *contract.ContractPriceChanged,
+	out chan<- pricefeed.Price,
+) error {
+	if event.Raw.Removed {
+		if s.dropPending(event) {
+			log.Printf("🔙 Dropped unconfirmed %s event removed from block %d", event.Symbol, event.Raw.BlockNumber)
+
+			return nil
+		}
+
+		price, ok := s.rollbackPriceChanged(event)
+		s.forgetHistory(event)
+		s.saveState()
+
+		if !ok {
+			return nil
+		}
+
+		return emit(ctx, out, price)
+	}
+
+	if s.cfg.EventConfirmations > 0 {
+		s.queuePending(event)
+
+		return nil
+	}
+
+	return s.applyLive(ctx, event, out)
+}
+
+// applyLive logs and applies a live event and forwards its price to out
+func (s *SepoliaPriceFeed) applyLive(ctx context.Context, event *contract.ContractPriceChanged,
+	out chan<- pricefeed.Price,
+) error {
+	log.Printf("🔥 Event:\n  Symbol: %s\n  Price: %d\n  Timestamp: %d\n  Block: %d\n",
+		event.Symbol, event.NewPrice, event.Timestamp.Uint64(), event.Raw.BlockNumber)
+
+	price := s.applyPriceChanged(event)
+	s.recordHistory(event, price)
+	s.saveState()
+
+	return emit(ctx, out, price)
+}
+
+// queuePending adds an event to pending in block order, unless its log is already waiting there
+func (s *SepoliaPriceFeed) queuePending(event *contract.ContractPriceChanged) {
+	at := len(s.pending)
+
+	for i, p := range s.pending {
+		if sameLog(p, event) {
+			return
+		}
+
+		if at == len(s.pending) && (p.Raw.BlockNumber > event.Raw.BlockNumber ||
+			p.Raw.BlockNumber == event.Raw.BlockNumber && p.Raw.Index > event.Raw.Index) {
+			at = i
+		}
+	}
+
+	s.pending = slices.Insert(s.pending, at, event)
+}
+
+// queueUnconfirmed adds the PriceChanged events mined after block up to the current head to pending
+func (s *SepoliaPriceFeed) queueUnconfirmed(ctx context.Context, block uint64) error {
+	head, err := s.backend.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch head block: %w", err)
+	}
+
+	if head <= block {
+		return nil
+	}
+
+	from := block + 1
+
+	it, err := s.contract.FilterPriceChanged(&bind.FilterOpts{Start: from, End: &head, Context: ctx})
+	if err != nil {
+		return fmt.Errorf("failed to filter PriceChanged events in blocks %d-%d: %w", from, head, err)
+	}
+
+	defer it.Close()
+
+	for it.Next() {
+		if !it.Event.Raw.Removed {
+			s.queuePending(it.Event)
+		}
+	}
+
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("failed to read PriceChanged events in blocks %d-%d: %w", from, head, err)
+	}
+
+	return nil
+}
+
+// releaseConfirmed applies the pending events that are EventConfirmations blocks deep
+func (s *SepoliaPriceFeed) releaseConfirmed(ctx context.Context, out chan<- pricefeed.Price) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	head, err := s.confirmedHead(ctx)
+	if err != nil {
+		return err
+	}
+
+	for len(s.pending) > 0 && s.pending[0].Raw.BlockNumber <= head {
+		event := s.pending[0]
+		s.pending = s.pending[1:]
+
+		if err := s.applyLive(ctx, event, out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropPending removes a pending event matching a removed log and reports whether it was found
+func (s *SepoliaPriceFeed) dropPending(event *contract.ContractPriceChanged) bool {
+	for i, p := range s.pending {
+		if sameLog(p, event) {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// dropPendingThrough discards pending events up to block, which a catch-up has already applied
+func (s *SepoliaPriceFeed) dropPendingThrough(block uint64) {
+	kept := s.pending[:0]
+
+	for _, p := range s.pending {
+		if p.Raw.BlockNumber > block {
+			kept = append(kept, p)
+		}
+	}
+
+	s.pending = kept
+}
+
+// sameLog reports whether two events come from the same log
+func sameLog(a, b *contract.ContractPriceChanged) bool {
+	return a.Raw.BlockHash == b.Raw.BlockHash && a.Raw.TxHash == b.Raw.TxHash && a.Raw.Index == b.Raw.Index
+}
+
+// confirmedHead returns the newest block buried under EventConfirmations blocks
+func (s *SepoliaPriceFeed) confirmedHead(ctx context.Context) (uint64, error) {
+	head, err := s.backend.BlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch head block: %w", err)
+	}
+
+	if head < s.cfg.EventConfirmations {
+		return 0, nil
+	}
+
+	return head - s.cfg.EventConfirmations, nil
+}
+
+// confirmationInterval returns how often the chain head is polled for confirmations
+func (s *SepoliaPriceFeed) confirmationInterval() time.Duration {
+	if s.pollInterval <= 0 {
+		return confirmationPollInterval
+	}
+
+	return s.pollInterval
+}
+
+// emit sends price to out unless ctx is canceled first
+func emit(ctx context.Context, out chan<- pricefeed.Price, price pricefeed.Price) error {
+	select {
+	case out <- price:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
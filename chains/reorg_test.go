@@ -0,0 +1,206 @@
+package chains
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sljivkov/dectek/contract"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// reorgEvent returns a PriceChanged event logged in the given block
+func reorgEvent(block uint64, price int64, removed bool) *contract.ContractPriceChanged {
+	return &contract.ContractPriceChanged{
+		Symbol:    "bitcoin",
+		NewPrice:  big.NewInt(price),
+		Timestamp: big.NewInt(1_700_000_000 + int64(block)),
+		Raw: types.Log{
+			BlockNumber: block,
+			BlockHash:   common.BigToHash(new(big.Int).SetUint64(block)),
+			TxHash:      common.BigToHash(big.NewInt(price)),
+			Removed:     removed,
+		},
+	}
+}
+
+func TestHandleEventRollback(t *testing.T) {
+	feed := &SepoliaPriceFeed{
+		cfg:           testConfig(),
+		onChainPrices: make(map[string]*big.Int),
+	}
+
+	ctx := context.Background()
+	out := make(chan pricefeed.Price, 10)
+
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(10, 3000000, false), out))
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(11, 3100000, false), out))
+	assert.Equal(t, "31000", feed.OnChainPrices()["bitcoin"].String())
+	assert.Equal(t, uint64(11), feed.LastBlock())
+
+	// Removing the latest event restores the value it replaced
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(11, 3100000, true), out))
+	assert.Equal(t, "30000", feed.OnChainPrices()["bitcoin"].String())
+	assert.Equal(t, int64(1_700_000_010), feed.updatedAt["bitcoin"].Unix())
+	assert.Equal(t, uint64(10), feed.LastBlock())
+
+	// A log that was never applied changes nothing
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(12, 3200000, true), out))
+	assert.Equal(t, "30000", feed.OnChainPrices()["bitcoin"].String())
+
+	// Removing the only event leaves no cached price
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(10, 3000000, true), out))
+	assert.NotContains(t, feed.OnChainPrices(), "bitcoin")
+
+	close(out)
+
+	var emitted []string
+	for p := range out {
+		emitted = append(emitted, p.USD.String())
+	}
+
+	// Two applied prices and one restored price
+	assert.Equal(t, []string{"30000", "31000", "30000"}, emitted)
+}
+
+func TestHandleEventRollbackOutOfOrder(t *testing.T) {
+	feed := &SepoliaPriceFeed{
+		cfg:           testConfig(),
+		onChainPrices: map[string]*big.Int{"bitcoin": big.NewInt(2900000)},
+	}
+
+	ctx := context.Background()
+	out := make(chan pricefeed.Price, 10)
+
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(10, 3000000, false), out))
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(11, 3100000, false), out))
+
+	// An earlier event is removed while a later one still holds
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(10, 3000000, true), out))
+	assert.Equal(t, "31000", feed.OnChainPrices()["bitcoin"].String())
+
+	// Removing the later one now falls back past both
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(11, 3100000, true), out))
+	assert.Equal(t, "29000", feed.OnChainPrices()["bitcoin"].String())
+}
+
+func TestHandleEventConfirmations(t *testing.T) {
+	mockBackend := new(MockTxBackend)
+
+	cfg := testConfig()
+	cfg.EventConfirmations = 2
+
+	feed := &SepoliaPriceFeed{
+		cfg:           cfg,
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+		pollInterval:  time.Millisecond,
+	}
+
+	ctx := context.Background()
+	out := make(chan pricefeed.Price, 10)
+
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(10, 3000000, false), out))
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(11, 3100000, false), out))
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(12, 3200000, false), out))
+	assert.Empty(t, feed.OnChainPrices())
+
+	// An unconfirmed event removed by a reorg is never applied
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(11, 3100000, true), out))
+
+	// Head 12 confirms block 10 only
+	mockBackend.On("BlockNumber").Return(uint64(12), nil).Once()
+	assert.NoError(t, feed.releaseConfirmed(ctx, out))
+	assert.Equal(t, "30000", feed.OnChainPrices()["bitcoin"].String())
+
+	mockBackend.On("BlockNumber").Return(uint64(14), nil).Once()
+	assert.NoError(t, feed.releaseConfirmed(ctx, out))
+	assert.Equal(t, "32000", feed.OnChainPrices()["bitcoin"].String())
+	assert.Empty(t, feed.pending)
+
+	close(out)
+
+	var emitted []string
+	for p := range out {
+		emitted = append(emitted, p.USD.String())
+	}
+
+	assert.Equal(t, []string{"30000", "32000"}, emitted)
+}
+
+func TestListenOnChainPriceUpdate_QueuesUnconfirmed(t *testing.T) {
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+	mockSub := new(MockSubscription)
+
+	cfg := testConfig()
+	cfg.EventConfirmations = 2
+
+	feed := &SepoliaPriceFeed{
+		cfg:           cfg,
+		contract:      mockContract,
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+		lastBlock:     100,
+		pollInterval:  10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Blocks 109 and 110 were mined before subscribing but are not yet confirmed
+	unconfirmed := &contract.ContractPriceChanged{
+		Symbol: "bitcoin", NewPrice: big.NewInt(3200000), Timestamp: big.NewInt(1_700_000_110),
+		Raw: types.Log{BlockNumber: 110, TxHash: common.BigToHash(big.NewInt(110))},
+	}
+
+	mockSub.On("Err").Return(make(chan error))
+	mockSub.On("Unsubscribe").Return()
+	mockContract.On("WatchPriceChanged", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		sink := args.Get(1).(chan<- *contract.ContractPriceChanged)
+		go func() {
+			// Also delivered by the subscription, it must only be applied once
+			sink <- unconfirmed
+		}()
+	}).Return(mockSub, nil)
+
+	mockBackend.On("BlockNumber").Return(uint64(110), nil).Twice()
+	mockBackend.On("BlockNumber").Return(uint64(112), nil)
+	mockContract.On("FilterPriceChanged", uint64(101), uint64(108)).Return(priceChangedIterator(t), nil).Once()
+	mockContract.On("FilterPriceChanged", uint64(109), uint64(110)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 109, symbol: "bitcoin", price: 3100000, timestamp: 1_700_000_109},
+		priceChangedLog{block: 110, symbol: "bitcoin", price: 3200000, timestamp: 1_700_000_110},
+	), nil).Once()
+
+	out := make(chan pricefeed.Price, 10)
+
+	go feed.ListenOnChainPriceUpdate(ctx, out)
+
+	var received []string
+
+	for len(received) < 2 {
+		select {
+		case price := <-out:
+			received = append(received, price.USD.String())
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for confirmed events")
+		}
+	}
+
+	assert.Equal(t, []string{"31000", "32000"}, received)
+
+	select {
+	case price := <-out:
+		t.Fatalf("event applied twice: %s", price.USD)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	mockContract.AssertExpectations(t)
+}
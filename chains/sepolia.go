@@ -6,6 +6,8 @@ import (
 	"log"
 	"math/big"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -14,7 +16,9 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/event"
 
+	"github.com/sljivkov/dectek/config"
 	"github.com/sljivkov/dectek/contract"
+	"github.com/sljivkov/dectek/history"
 	"github.com/sljivkov/dectek/pricefeed"
 )
 
@@ -23,29 +27,49 @@ type ContractInterface interface {
 	WatchPriceChanged(opts *bind.WatchOpts, sink chan<- *contract.ContractPriceChanged) (event.Subscription, error)
 	Set(opts *bind.TransactOpts, symbol string, price *big.Int) (*types.Transaction, error)
 	Get(opts *bind.CallOpts, symbol string) (*big.Int, error)
-}
-
-// ChainlinkPricer allows mocking getChainlinkPrice.
-type ChainlinkPricer interface {
-	getChainlinkPrice(symbol string) (int64, error)
+	FilterPriceChanged(opts *bind.FilterOpts) (*contract.ContractPriceChangedIterator, error)
 }
 
 type SepoliaPriceFeed struct {
+	cfg             config.Config
 	client          *ethclient.Client
+	backend         TxBackend // used to track sent transactions
 	contract        ContractInterface
 	auth            *bind.TransactOpts
 	contractAddress common.Address
-	onChainPrices   map[string]float64
-	chainlinkPricer ChainlinkPricer
+	onChainPrices   map[string]*big.Int              // symbol -> on-chain fixed-point price
+	updatedAt       map[string]time.Time             // symbol -> timestamp of the last PriceChanged event
+	lastBlock       uint64                           // highest block whose PriceChanged events were applied
+	applied         map[string][]appliedEvent        // symbol -> recent applied events, oldest first
+	pending         []*contract.ContractPriceChanged // live events awaiting EventConfirmations
+	pricesMu        sync.RWMutex
+	reference       ReferencePricer // sanity band reference, nil disables the check
+	nonces          *nonceManager   // allocates nonces for price writes
+	gas             GasStrategy     // suggests write fees, nil keeps go-ethereum defaults
+	maxFee          *big.Int        // fee cap ceiling for replacements, nil disables
+
+	divergences   map[string]Divergence // symbol -> cache/storage mismatch found by Reconcile
+	divergencesMu sync.Mutex
+
+	state   StateStore // persists progress across restarts, nil disables
+	stateMu sync.Mutex
+
+	priceHistory *history.Store // records applied PriceChanged events, nil disables
+
+	writes       map[string]WriteResult // symbol -> latest write result
+	inFlight     map[string]*big.Int    // symbol -> price of the newest write still being tracked
+	writesMu     sync.Mutex
+	trackers     sync.WaitGroup
+	pollInterval time.Duration // confirmation polling interval, defaults to confirmationPollInterval
 }
 
-func NewSepoliaPriceFeed(privateKey string, rpcURL string, contractAddress string) (*SepoliaPriceFeed, error) {
-	client, err := ethclient.Dial(rpcURL)
-	if err != nil {
-		return nil, err
-	}
-
-	ecdsaKey, err := crypto.HexToECDSA(privateKey)
+// NewSepoliaPriceFeed creates the chain feed. Writes are sanity checked against reference,
+// which may be a FallbackReference, or nil to skip the check. Applied PriceChanged events are
+// recorded in priceHistory unless it is nil.
+func NewSepoliaPriceFeed(cfg config.Config, client *ethclient.Client,
+	reference ReferencePricer, priceHistory *history.Store,
+) (*SepoliaPriceFeed, error) {
+	ecdsaKey, err := crypto.HexToECDSA(cfg.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -60,134 +84,350 @@ func NewSepoliaPriceFeed(privateKey string, rpcURL string, contractAddress strin
 		return nil, err
 	}
 
-	addr := common.HexToAddress(contractAddress)
+	addr := common.HexToAddress(cfg.Contract)
 
 	contract, err := contract.NewContract(addr, client)
 	if err != nil {
 		return nil, err
 	}
 
+	gas, err := NewGasStrategy(cfg, client)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.ReferenceUnavailable {
+	case ReferenceSkip, ReferenceAllow, "":
+	default:
+		return nil, fmt.Errorf("unknown reference unavailable policy %q", cfg.ReferenceUnavailable)
+	}
+
 	feed := &SepoliaPriceFeed{
+		cfg:             cfg,
 		client:          client,
+		backend:         client,
 		contract:        contract,
 		auth:            auth,
 		contractAddress: addr,
-		onChainPrices:   make(map[string]float64),
+		onChainPrices:   make(map[string]*big.Int),
+		updatedAt:       make(map[string]time.Time),
+		nonces:          newNonceManager(client, auth.From),
+		gas:             gas,
+		writes:          make(map[string]WriteResult),
+		reference:       reference,
+		priceHistory:    priceHistory,
+	}
+
+	if cfg.GasMaxFeeGwei > 0 {
+		feed.maxFee = gweiToWei(cfg.GasMaxFeeGwei)
+	}
+
+	if cfg.StateFile != "" {
+		feed.state = NewFileStateStore(cfg.StateFile)
 	}
-	// chainlinkPricer will be set by the caller
+
+	if err := feed.restoreState(); err != nil {
+		return nil, err
+	}
+
 	return feed, nil
 }
 
-func (s *SepoliaPriceFeed) ListenOnChainPriceUpdate(ctx context.Context, out chan<- pricefeed.Price) {
-	logs := make(chan *contract.ContractPriceChanged)
+// defaultResubscribeBackoff is the first resubscribe delay when none is configured
+const defaultResubscribeBackoff = time.Second
 
-	sub, err := s.contract.WatchPriceChanged(&bind.WatchOpts{
-		Context: ctx,
-	}, logs)
-	if err != nil {
-		log.Fatalf("Failed to subscribe to event: %v", err)
+// ListenOnChainPriceUpdate forwards PriceChanged events to out until ctx is canceled, then closes out.
+// A failed subscription is retried with exponential backoff, and events emitted while disconnected
+// are caught up with FilterPriceChanged before live events are processed. HTTP RPC endpoints cannot
+// subscribe, so for those the logs are polled instead.
+func (s *SepoliaPriceFeed) ListenOnChainPriceUpdate(ctx context.Context, out chan<- pricefeed.Price) {
+	listen := s.listen
+	if usesPolling(s.cfg.Alchemy) {
+		listen = s.poll
 	}
 
-	log.Println("📡 Listening for PriceChanged events...")
-
 	go func() {
-		defer sub.Unsubscribe() // Always cleanup subscription
-
 		defer close(out) // Always close output channel when done
 
+		initial := s.cfg.ResubscribeBackoff
+		if initial <= 0 {
+			initial = defaultResubscribeBackoff
+		}
+
+		maxBackoff := max(s.cfg.ResubscribeMaxBackoff, initial)
+		backoff := initial
+
 		for {
-			select {
-			case err := <-sub.Err():
-				log.Printf("🔴 Subscription error: %v", err)
+			connected, err := listen(ctx, out)
+			if ctx.Err() != nil {
+				log.Println("🛑 Context canceled, stopping listener")
 
 				return
-			case event := <-logs:
-				log.Printf("🔥 Event:\n  Symbol: %s\n  Price: %d\n  Timestamp: %d\n",
-					event.Symbol, event.NewPrice, event.Timestamp.Uint64())
+			}
 
-				priceFloat, _ := new(big.Float).SetInt(
-					new(big.Int).Div(event.NewPrice, big.NewInt(100)),
-				).Float64()
+			if connected {
+				backoff = initial
+			}
 
-				s.onChainPrices[event.Symbol] = priceFloat
+			log.Printf("🔴 PriceChanged listener error: %v, retrying in %s", err, backoff)
 
-				out <- pricefeed.Price{
-					Symbol: event.Symbol,
-					USD:    priceFloat,
-				}
+			select {
+			case <-time.After(backoff):
 			case <-ctx.Done():
 				log.Println("🛑 Context canceled, stopping listener")
 
 				return
 			}
+
+			backoff = min(2*backoff, maxBackoff)
 		}
 	}()
 }
 
-func (s *SepoliaPriceFeed) validatePrice(symbol string, newPrice int64) (bool, error) {
-	chainlinkPrice, err := s.chainlinkPricer.getChainlinkPrice(symbol)
+// listen subscribes to PriceChanged events, catches up on blocks missed since the last applied
+// event and forwards live events to out until the subscription fails or ctx is canceled.
+// It reports whether the subscription was established.
+func (s *SepoliaPriceFeed) listen(ctx context.Context, out chan<- pricefeed.Price) (bool, error) {
+	logs := make(chan *contract.ContractPriceChanged)
+
+	sub, err := s.contract.WatchPriceChanged(&bind.WatchOpts{
+		Context: ctx,
+	}, logs)
+	if err != nil {
+		return false, fmt.Errorf("failed to subscribe to event: %w", err)
+	}
+
+	defer sub.Unsubscribe() // Always cleanup subscription
+
+	// New events are buffered by the subscription while the missed range is replayed
+	caughtUp, err := s.catchUp(ctx, out)
 	if err != nil {
-		return false, fmt.Errorf("chainlink fetch failed for %s: %w", symbol, err)
+		return true, err
 	}
 
-	contractPrice := int64(s.onChainPrices[symbol] * 100)
-	chainlinkScaled := chainlinkPrice * 100
+	s.dropPendingThrough(caughtUp)
+
+	// The catch-up stops EventConfirmations blocks below the head and the subscription
+	// only delivers logs of new blocks, so the unconfirmed blocks in between are read here
+	if s.cfg.EventConfirmations > 0 {
+		if err := s.queueUnconfirmed(ctx, caughtUp); err != nil {
+			return true, err
+		}
+	}
+
+	// Pending events are released as the head advances
+	var confirmTicker <-chan time.Time
+
+	if s.cfg.EventConfirmations > 0 {
+		ticker := time.NewTicker(s.confirmationInterval())
+		defer ticker.Stop()
+
+		confirmTicker = ticker.C
+	}
+
+	log.Println("📡 Listening for PriceChanged events...")
+
+	for {
+		select {
+		case err := <-sub.Err():
+			return true, err
+		case event := <-logs:
+			// Skip events already applied by the catch-up
+			if !event.Raw.Removed && event.Raw.BlockNumber != 0 && event.Raw.BlockNumber <= caughtUp {
+				continue
+			}
 
-	// If no contract price exists, only check chainlink bounds
-	if contractPrice == 0 {
-		chainUp := int64(float64(chainlinkScaled) * 1.2)   // 20% up
-		chainDown := int64(float64(chainlinkScaled) * 0.8) // 20% down
+			if err := s.handleEvent(ctx, event, out); err != nil {
+				return true, err
+			}
+		case <-confirmTicker:
+			if err := s.releaseConfirmed(ctx, out); err != nil {
+				return true, err
+			}
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+}
 
-		return newPrice >= chainDown && newPrice <= chainUp, nil
+// catchUp replays the events after the last applied block and returns the block it caught up to.
+// Without an applied block there is nothing to catch up on, the current head becomes the baseline.
+func (s *SepoliaPriceFeed) catchUp(ctx context.Context, out chan<- pricefeed.Price) (uint64, error) {
+	last := s.LastBlock()
+	if last > 0 {
+		return s.replay(ctx, last+1, out)
 	}
 
-	// First check if price is within 2% of contract price
-	contractUp := int64(float64(contractPrice) * 1.02)   // 2% up
-	contractDown := int64(float64(contractPrice) * 0.98) // 2% down
-	withinContractBounds := newPrice >= contractDown && newPrice <= contractUp
+	head, err := s.confirmedHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	s.setLastBlock(head)
+	s.saveState()
+
+	return head, nil
+}
+
+// validatePrice decides whether newPrice, in the token's on-chain fixed-point units, should be
+// written using the token's deviation threshold, heartbeat, absolute limits and sanity band around the reference
+func (s *SepoliaPriceFeed) validatePrice(ctx context.Context, symbol string, newPrice *big.Int) (bool, error) {
+	policy := s.cfg.Token(symbol)
+
+	minPrice := pricefeed.DecimalFromFloat(policy.MinPrice).ToUnits(policy.Decimals)
+	if policy.MinPrice > 0 && newPrice.Cmp(minPrice) < 0 {
+		return false, fmt.Errorf("%s price %s below minimum %v", symbol, newPrice, policy.MinPrice)
+	}
+
+	maxPrice := pricefeed.DecimalFromFloat(policy.MaxPrice).ToUnits(policy.Decimals)
+	if policy.MaxPrice > 0 && newPrice.Cmp(maxPrice) > 0 {
+		return false, fmt.Errorf("%s price %s above maximum %v", symbol, newPrice, policy.MaxPrice)
+	}
+
+	// A write still being tracked will become the contract price, so newPrice is compared against it.
+	// Otherwise every batch arriving before it is mined would send another write.
+	if pending := s.inFlightPrice(symbol); pending != nil && withinBand(newPrice, pending, policy.Deviation) {
+		log.Printf("⏳ %s write of %s still pending", symbol, pending)
 
-	// If price is within 2% bounds, don't write (avoid unnecessary updates)
-	if withinContractBounds {
 		return false, nil
 	}
 
-	// If price is outside contract bounds, verify it's within chainlink bounds
-	chainUp := int64(float64(chainlinkScaled) * 1.2)   // 20% up
-	chainDown := int64(float64(chainlinkScaled) * 0.8) // 20% down
-	withinChainlinkBounds := newPrice >= chainDown && newPrice <= chainUp
+	withinReferenceBounds, err := s.checkReference(ctx, symbol, newPrice, policy)
+	if err != nil {
+		return false, err
+	}
+
+	// If no contract price exists, only check the reference bounds
+	contractPrice := s.onChainPrice(symbol)
+	if contractPrice == nil || contractPrice.Sign() == 0 {
+		return withinReferenceBounds, nil
+	}
+
+	// If price is within the deviation threshold of the contract price, don't write
+	// (avoid unnecessary updates) unless the heartbeat is due
+	if withinBand(newPrice, contractPrice, policy.Deviation) {
+		if !s.heartbeatDue(symbol) {
+			return false, nil
+		}
+
+		log.Printf("💓 %s heartbeat due, refreshing on-chain price", symbol)
+	}
+
+	return withinReferenceBounds, nil
+}
+
+// checkReference reports whether newPrice lies within the sanity band around the reference price.
+// When no reference is available the write is refused, unless REFERENCE_UNAVAILABLE is "allow".
+func (s *SepoliaPriceFeed) checkReference(ctx context.Context, symbol string, newPrice *big.Int,
+	policy config.TokenConfig,
+) (bool, error) {
+	if s.reference == nil {
+		return true, nil
+	}
+
+	ref, err := s.reference.ReferencePrice(ctx, symbol)
+	if err != nil {
+		if s.cfg.ReferenceUnavailable == ReferenceAllow {
+			log.Printf("⚠️ No reference price for %s, writing without sanity check: %v", symbol, err)
+
+			return true, nil
+		}
+
+		return false, fmt.Errorf("reference price unavailable for %s: %w", symbol, err)
+	}
+
+	return withinBand(newPrice, ref.ToUnits(policy.Decimals), policy.SanityBand), nil
+}
 
-	return withinChainlinkBounds, nil
+// withinBand reports whether v lies within ref * (1 ± band)
+func withinBand(v, ref *big.Int, band float64) bool {
+	diff := new(big.Rat).SetInt(new(big.Int).Abs(new(big.Int).Sub(v, ref)))
+	limit := new(big.Rat).Mul(new(big.Rat).SetInt(ref), new(big.Rat).SetFloat64(band))
+
+	return diff.Cmp(limit) <= 0
 }
 
-func (s *SepoliaPriceFeed) writeToChain(_ context.Context, symbol string, price float64) error {
-	newPrice := big.NewInt(int64(price * 100))
+// writeToChain sends a price update and starts tracking it. The cache is only
+// updated once the transaction is mined with the configured confirmations.
+func (s *SepoliaPriceFeed) writeToChain(ctx context.Context, symbol string, price *big.Int) error {
+	var tip, feeCap *big.Int
+
+	if s.gas != nil {
+		var err error
 
-	_, err := s.contract.Set(s.auth, symbol, newPrice)
+		tip, feeCap, err = s.gas.Fees(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to price %s write: %w", symbol, err)
+		}
+	}
+
+	nonce, err := s.nonces.Next(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to write %s price: %w", symbol, err)
 	}
 
-	// Update cache after successful write
-	s.onChainPrices[symbol] = price
+	tx, err := s.sendPrice(ctx, symbol, price, nonce, tip, feeCap)
+	if err != nil {
+		// The nonce may or may not have been consumed, let the node decide
+		s.nonces.Reset()
+
+		return fmt.Errorf("failed to write %s price: %w", symbol, err)
+	}
+
+	s.recordWrite(WriteResult{
+		Symbol: symbol,
+		Price:  price,
+		TxHash: tx.Hash(),
+		Status: TxPending,
+	})
+	s.setInFlight(symbol, price)
+	s.saveState()
+
+	s.trackers.Add(1)
+
+	go s.trackWrite(ctx, symbol, price, tx)
 
 	return nil
 }
 
+// sendPrice sends a Set transaction with the given nonce and fees, nil fees use go-ethereum defaults
+func (s *SepoliaPriceFeed) sendPrice(ctx context.Context, symbol string, price *big.Int, nonce uint64,
+	tip, feeCap *big.Int,
+) (*types.Transaction, error) {
+	opts := *s.auth
+	opts.Context = ctx
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	opts.GasTipCap = tip
+	opts.GasFeeCap = feeCap
+
+	return s.contract.Set(&opts, symbol, price)
+}
+
+// WritePricesToChain validates and writes incoming prices until ctx is canceled or in is closed.
+// Before returning it waits for already sent transactions to settle, bounded by drainTimeout.
 func (s *SepoliaPriceFeed) WritePricesToChain(ctx context.Context, in <-chan []pricefeed.Price) {
+	defer s.waitPending(s.drainTimeout())
+
 	for {
 		select {
-		case prices := <-in:
+		case prices, ok := <-in:
+			if !ok {
+				log.Println("⛔ Price writing stopped, input channel closed")
+
+				return
+			}
+
 			log.Printf("📨 Incoming prices to chain writer: %+v\n", prices)
 
 			// First validate all prices
-			validPrices := make([]pricefeed.Price, 0)
+			validPrices := make(map[string]*big.Int)
 
 			for _, price := range prices {
 				symbol := strings.ToLower(price.Symbol)
 
-				newPrice := int64(price.USD * 100)
+				newPrice := price.USD.ToUnits(s.cfg.Token(symbol).Decimals)
 
-				shouldWrite, err := s.validatePrice(symbol, newPrice)
+				shouldWrite, err := s.validatePrice(ctx, symbol, newPrice)
 				if err != nil {
 					log.Printf("⚠️ %v", err)
 
@@ -195,24 +435,23 @@ func (s *SepoliaPriceFeed) WritePricesToChain(ctx context.Context, in <-chan []p
 				}
 
 				if shouldWrite {
-					log.Printf("✅ Price validated for %s: %.2f", symbol, price.USD)
+					log.Printf("✅ Price validated for %s: %s", symbol, price.USD)
 
-					validPrices = append(validPrices, price)
+					validPrices[symbol] = newPrice
 				} else {
-					log.Printf("⛔ %s price invalid for write: %.2f", symbol, price.USD)
+					log.Printf("⛔ %s price invalid for write: %s", symbol, price.USD)
 				}
 			}
 
 			// Then write valid prices
-			for _, price := range validPrices {
-				symbol := strings.ToLower(price.Symbol)
-				if err := s.writeToChain(ctx, symbol, price.USD); err != nil {
+			for symbol, price := range validPrices {
+				if err := s.writeToChain(ctx, symbol, price); err != nil {
 					log.Printf("❌ %v", err)
 
 					continue
 				}
 
-				log.Printf("📝 Successfully wrote %s price: %.2f", symbol, price.USD)
+				log.Printf("📝 Submitted %s price: %s", symbol, price)
 			}
 
 		case <-ctx.Done():
@@ -223,6 +462,96 @@ func (s *SepoliaPriceFeed) WritePricesToChain(ctx context.Context, in <-chan []p
 	}
 }
 
-func (sp *SepoliaPriceFeed) OnChainPrices() map[string]float64 {
-	return sp.onChainPrices
+func (sp *SepoliaPriceFeed) OnChainPrices() map[string]pricefeed.Decimal {
+	sp.pricesMu.RLock()
+	defer sp.pricesMu.RUnlock()
+
+	prices := make(map[string]pricefeed.Decimal, len(sp.onChainPrices))
+	for symbol, price := range sp.onChainPrices {
+		prices[symbol] = pricefeed.DecimalFromUnits(price, sp.cfg.Token(symbol).Decimals)
+	}
+
+	return prices
+}
+
+// onChainPrice returns the cached on-chain fixed-point price for a symbol, or nil when unknown
+func (sp *SepoliaPriceFeed) onChainPrice(symbol string) *big.Int {
+	sp.pricesMu.RLock()
+	defer sp.pricesMu.RUnlock()
+
+	return sp.onChainPrices[symbol]
+}
+
+// setOnChainPrice updates the cached on-chain fixed-point price for a symbol
+func (sp *SepoliaPriceFeed) setOnChainPrice(symbol string, price *big.Int) {
+	sp.pricesMu.Lock()
+	defer sp.pricesMu.Unlock()
+
+	sp.onChainPrices[symbol] = new(big.Int).Set(price)
+}
+
+// setUpdatedAt records when the on-chain price for a symbol last changed
+func (sp *SepoliaPriceFeed) setUpdatedAt(symbol string, at time.Time) {
+	sp.pricesMu.Lock()
+	defer sp.pricesMu.Unlock()
+
+	if sp.updatedAt == nil {
+		sp.updatedAt = make(map[string]time.Time)
+	}
+
+	sp.updatedAt[symbol] = at
+}
+
+// applyPriceChanged updates the cache from a PriceChanged event and returns the new price.
+// The replaced value is remembered so that the event can be rolled back on a reorg.
+func (sp *SepoliaPriceFeed) applyPriceChanged(event *contract.ContractPriceChanged) pricefeed.Price {
+	sp.pricesMu.Lock()
+	defer sp.pricesMu.Unlock()
+
+	if sp.updatedAt == nil {
+		sp.updatedAt = make(map[string]time.Time)
+	}
+
+	sp.recordApplied(event)
+
+	sp.onChainPrices[event.Symbol] = new(big.Int).Set(event.NewPrice)
+	sp.updatedAt[event.Symbol] = time.Unix(event.Timestamp.Int64(), 0)
+	sp.lastBlock = max(sp.lastBlock, event.Raw.BlockNumber)
+
+	return pricefeed.Price{
+		Symbol: event.Symbol,
+		USD:    pricefeed.DecimalFromUnits(event.NewPrice, sp.cfg.Token(event.Symbol).Decimals),
+	}
+}
+
+// setLastBlock records that events up to block have been applied
+func (sp *SepoliaPriceFeed) setLastBlock(block uint64) {
+	sp.pricesMu.Lock()
+	defer sp.pricesMu.Unlock()
+
+	sp.lastBlock = max(sp.lastBlock, block)
+}
+
+// LastBlock returns the highest block whose PriceChanged events have been applied
+func (sp *SepoliaPriceFeed) LastBlock() uint64 {
+	sp.pricesMu.RLock()
+	defer sp.pricesMu.RUnlock()
+
+	return sp.lastBlock
+}
+
+// heartbeatDue reports whether the on-chain price for a symbol is older than its heartbeat.
+// A price with no known timestamp is treated as stale; Reconcile seeds one for the prices it reads.
+func (sp *SepoliaPriceFeed) heartbeatDue(symbol string) bool {
+	interval := sp.cfg.Token(symbol).Heartbeat
+
+	if interval <= 0 {
+		return false
+	}
+
+	sp.pricesMu.RLock()
+	updated, ok := sp.updatedAt[symbol]
+	sp.pricesMu.RUnlock()
+
+	return !ok || time.Since(updated) >= interval
 }
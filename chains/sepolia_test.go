@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/sljivkov/dectek/config"
 	"github.com/sljivkov/dectek/contract"
 	"github.com/sljivkov/dectek/pricefeed"
 )
@@ -42,6 +44,74 @@ func (m *MockContract) Get(opts *bind.CallOpts, symbol string) (*big.Int, error)
 	return args.Get(0).(*big.Int), args.Error(1)
 }
 
+func (m *MockContract) FilterPriceChanged(opts *bind.FilterOpts) (*contract.ContractPriceChangedIterator, error) {
+	args := m.Called(opts.Start, *opts.End)
+
+	it, _ := args.Get(0).(*contract.ContractPriceChangedIterator)
+
+	return it, args.Error(1)
+}
+
+// MockTxBackend implements TxBackend for testing
+type MockTxBackend struct {
+	mock.Mock
+}
+
+func (m *MockTxBackend) TransactionReceipt(_ context.Context, txHash common.Hash) (*types.Receipt, error) {
+	args := m.Called(txHash)
+
+	receipt, _ := args.Get(0).(*types.Receipt)
+
+	return receipt, args.Error(1)
+}
+
+func (m *MockTxBackend) CodeAt(_ context.Context, account common.Address, _ *big.Int) ([]byte, error) {
+	args := m.Called(account)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockTxBackend) BlockNumber(_ context.Context) (uint64, error) {
+	args := m.Called()
+
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+//nolint:lll
+func (m *MockTxBackend) TransactionByHash(_ context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	args := m.Called(hash)
+
+	tx, _ := args.Get(0).(*types.Transaction)
+
+	return tx, args.Bool(1), args.Error(2)
+}
+
+func (m *MockTxBackend) PendingNonceAt(_ context.Context, account common.Address) (uint64, error) {
+	args := m.Called(account)
+
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+// testConfig returns a config with the default write policy and short timeouts
+func testConfig() config.Config {
+	return config.Config{
+		Deviation:       0.02,
+		SanityBand:      0.2,
+		Decimals:        2,
+		TxTimeout:       time.Second,
+		ShutdownTimeout: time.Second,
+	}
+}
+
+// minedReceipt returns a successful receipt in the given block
+func minedReceipt(block int64) *types.Receipt {
+	return &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		BlockNumber: big.NewInt(block),
+		BlockHash:   common.BigToHash(big.NewInt(block)),
+	}
+}
+
 // MockSubscription implements event.Subscription
 type MockSubscription struct {
 	mock.Mock
@@ -60,12 +130,17 @@ func (m *MockSubscription) Err() <-chan error {
 func TestListenOnChainPriceUpdate(t *testing.T) {
 	mockContract := new(MockContract)
 	mockSub := new(MockSubscription)
+	mockBackend := new(MockTxBackend)
 
 	feed := &SepoliaPriceFeed{
+		cfg:           config.Config{Heartbeat: time.Hour, Decimals: 2},
 		contract:      mockContract,
-		onChainPrices: make(map[string]float64),
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
 	}
 
+	mockBackend.On("BlockNumber").Return(uint64(100), nil)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -94,7 +169,8 @@ func TestListenOnChainPriceUpdate(t *testing.T) {
 	case price := <-out:
 		assert.Equal(t, "bitcoin", price.Symbol)
 
-		assert.Equal(t, 30000.00, price.USD)
+		assert.Equal(t, "30000", price.USD.String())
+		assert.False(t, feed.heartbeatDue("bitcoin"))
 
 	case <-time.After(2 * time.Second):
 		t.Fatal("timeout waiting for price update")
@@ -107,13 +183,21 @@ func TestListenOnChainPriceUpdate(t *testing.T) {
 	mockContract.AssertExpectations(t)
 }
 
-func TestListenOnChainPriceUpdate_Error(t *testing.T) {
+func TestListenOnChainPriceUpdate_Resubscribe(t *testing.T) {
 	mockContract := new(MockContract)
-	mockSub := new(MockSubscription)
+	mockBackend := new(MockTxBackend)
+	failing := new(MockSubscription)
+	healthy := new(MockSubscription)
+
+	cfg := testConfig()
+	cfg.ResubscribeBackoff = 10 * time.Millisecond
 
 	feed := &SepoliaPriceFeed{
+		cfg:           cfg,
 		contract:      mockContract,
-		onChainPrices: make(map[string]float64),
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+		lastBlock:     100,
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -122,52 +206,100 @@ func TestListenOnChainPriceUpdate_Error(t *testing.T) {
 	out := make(chan pricefeed.Price)
 	errChan := make(chan error, 1)
 
-	// Setup mock expectations
-	mockSub.On("Err").Return(errChan)
-	mockSub.On("Unsubscribe").Return()
-	mockContract.On("WatchPriceChanged", mock.Anything, mock.Anything).Return(mockSub, nil)
+	failing.On("Err").Return(errChan)
+	failing.On("Unsubscribe").Return()
+	healthy.On("Err").Return(make(chan error))
+	healthy.On("Unsubscribe").Return()
+
+	// The first subscription attempt fails, the second drops, the third stays up
+	mockContract.On("WatchPriceChanged", mock.Anything, mock.Anything).
+		Return((*MockSubscription)(nil), fmt.Errorf("dial failed")).Once()
+	mockContract.On("WatchPriceChanged", mock.Anything, mock.Anything).Return(failing, nil).Once()
+	mockContract.On("WatchPriceChanged", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		sink := args.Get(1).(chan<- *contract.ContractPriceChanged)
+		go func() {
+			// Already replayed by the catch-up
+			sink <- &contract.ContractPriceChanged{
+				Symbol: "bitcoin", NewPrice: big.NewInt(3100000), Timestamp: big.NewInt(1_700_000_100),
+				Raw: types.Log{BlockNumber: 105},
+			}
+			sink <- &contract.ContractPriceChanged{
+				Symbol: "bitcoin", NewPrice: big.NewInt(3200000), Timestamp: big.NewInt(1_700_000_200),
+				Raw: types.Log{BlockNumber: 111},
+			}
+		}()
+	}).Return(healthy, nil).Once()
+
+	// Nothing was missed before the drop, blocks 101-110 were missed while reconnecting
+	mockBackend.On("BlockNumber").Return(uint64(100), nil).Once()
+	mockBackend.On("BlockNumber").Return(uint64(110), nil).Once()
+	mockContract.On("FilterPriceChanged", uint64(101), uint64(110)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 105, symbol: "bitcoin", price: 3100000, timestamp: 1_700_000_100},
+	), nil).Once()
 
-	// Start listening in a goroutine
 	go feed.ListenOnChainPriceUpdate(ctx, out)
 
-	// Send an error through the subscription
-	errChan <- fmt.Errorf("test error")
+	errChan <- fmt.Errorf("connection reset")
 
-	// Give some time for error processing
-	time.Sleep(100 * time.Millisecond)
+	var received []string
+
+	for len(received) < 2 {
+		select {
+		case price := <-out:
+			received = append(received, price.USD.String())
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for price update")
+		}
+	}
+
+	assert.Equal(t, []string{"31000", "32000"}, received)
+	assert.Equal(t, uint64(111), feed.LastBlock())
 
-	// Test cleanup
 	cancel()
-	mockSub.AssertExpectations(t)
+
+	// out is closed once the listener stops
+	select {
+	case _, ok := <-out:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("listener did not stop")
+	}
+
 	mockContract.AssertExpectations(t)
+	mockBackend.AssertExpectations(t)
 }
 
-// MockSepoliaPriceFeed embeds SepoliaPriceFeed and allows mocking getChainlinkPrice
+// MockSepoliaPriceFeed embeds SepoliaPriceFeed and allows mocking the reference price
 type MockSepoliaPriceFeed struct {
 	mock.Mock
 	*SepoliaPriceFeed
 }
 
-// MockChainlinkPricer implements ChainlinkPricer for testing
-type MockChainlinkPricer struct {
+// MockReferencePricer implements ReferencePricer for testing
+type MockReferencePricer struct {
 	mock.Mock
 }
 
-func (m *MockChainlinkPricer) getChainlinkPrice(symbol string) (int64, error) {
+func (m *MockReferencePricer) Name() string {
+	return "mock"
+}
+
+func (m *MockReferencePricer) ReferencePrice(_ context.Context, symbol string) (pricefeed.Decimal, error) {
 	args := m.Called(symbol)
 
-	return args.Get(0).(int64), args.Error(1)
+	return args.Get(0).(pricefeed.Decimal), args.Error(1)
 }
 
 func TestValidatePrice(t *testing.T) {
 	mockContract := new(MockContract)
-	mockPricer := new(MockChainlinkPricer)
+	mockPricer := new(MockReferencePricer)
 	feed := &SepoliaPriceFeed{
+		cfg:      testConfig(),
 		contract: mockContract,
-		onChainPrices: map[string]float64{
-			"bitcoin": 30000.00,
+		onChainPrices: map[string]*big.Int{
+			"bitcoin": big.NewInt(3000000),
 		},
-		chainlinkPricer: mockPricer,
+		reference: mockPricer,
 	}
 
 	tests := []struct {
@@ -207,12 +339,13 @@ func TestValidatePrice(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.wantErr {
-				mockPricer.On("getChainlinkPrice", tt.symbol).Return(int64(0), fmt.Errorf("chainlink error")).Once()
+				mockPricer.On("ReferencePrice", tt.symbol).Return(pricefeed.Decimal{}, fmt.Errorf("reference error")).Once()
 			} else {
-				mockPricer.On("getChainlinkPrice", tt.symbol).Return(tt.chainlinkPrice, nil).Once()
+				reference := pricefeed.DecimalFromInt(tt.chainlinkPrice)
+				mockPricer.On("ReferencePrice", tt.symbol).Return(reference, nil).Once()
 			}
 
-			got, err := feed.validatePrice(tt.symbol, tt.price)
+			got, err := feed.validatePrice(context.Background(), tt.symbol, big.NewInt(tt.price))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("validatePrice() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -226,14 +359,150 @@ func TestValidatePrice(t *testing.T) {
 	}
 }
 
+func TestValidatePrice_Heartbeat(t *testing.T) {
+	mockPricer := new(MockReferencePricer)
+	feed := &SepoliaPriceFeed{
+		cfg: testConfig(),
+		onChainPrices: map[string]*big.Int{
+			"bitcoin":  big.NewInt(3000000),
+			"ethereum": big.NewInt(200000),
+			"solana":   big.NewInt(15000),
+		},
+		updatedAt: map[string]time.Time{
+			"bitcoin":  time.Now().Add(-30 * time.Minute),
+			"ethereum": time.Now().Add(-30 * time.Minute),
+		},
+		reference: mockPricer,
+	}
+
+	feed.cfg.Heartbeat = time.Hour
+	feed.cfg.Heartbeats = map[string]time.Duration{"ethereum": 10 * time.Minute}
+
+	mockPricer.On("ReferencePrice", "bitcoin").Return(pricefeed.DecimalFromInt(30000), nil)
+	mockPricer.On("ReferencePrice", "ethereum").Return(pricefeed.DecimalFromInt(2000), nil)
+	mockPricer.On("ReferencePrice", "solana").Return(pricefeed.DecimalFromInt(150), nil)
+
+	// Bitcoin was updated within its heartbeat, so an unchanged price is skipped
+	got, err := feed.validatePrice(context.Background(), "bitcoin", big.NewInt(3000000))
+	assert.NoError(t, err)
+	assert.False(t, got)
+
+	// Ethereum's per-token heartbeat has elapsed, so the unchanged price is written
+	got, err = feed.validatePrice(context.Background(), "ethereum", big.NewInt(200000))
+	assert.NoError(t, err)
+	assert.True(t, got)
+
+	// Once the event timestamp is refreshed the heartbeat is satisfied again
+	feed.setUpdatedAt("ethereum", time.Now())
+
+	got, err = feed.validatePrice(context.Background(), "ethereum", big.NewInt(200000))
+	assert.NoError(t, err)
+	assert.False(t, got)
+
+	// Solana's price has no known event timestamp, so its age is unknown and it is written
+	got, err = feed.validatePrice(context.Background(), "solana", big.NewInt(15000))
+	assert.NoError(t, err)
+	assert.True(t, got)
+}
+
+func TestValidatePrice_TokenPolicy(t *testing.T) {
+	mockPricer := new(MockReferencePricer)
+	feed := &SepoliaPriceFeed{
+		cfg: testConfig(),
+		onChainPrices: map[string]*big.Int{
+			"tether": big.NewInt(100),
+		},
+		reference: mockPricer,
+	}
+
+	feed.cfg.Deviations = map[string]float64{"tether": 0.001}
+	feed.cfg.SanityBands = map[string]float64{"tether": 0.05}
+	feed.cfg.MinPrices = map[string]float64{"tether": 0.90}
+	feed.cfg.MaxPrices = map[string]float64{"tether": 1.10}
+
+	mockPricer.On("ReferencePrice", "tether").Return(pricefeed.DecimalFromInt(1), nil)
+
+	// A 1 cent move exceeds the tighter stablecoin deviation
+	got, err := feed.validatePrice(context.Background(), "tether", big.NewInt(101))
+	assert.NoError(t, err)
+	assert.True(t, got)
+
+	// Inside the limits but outside the 5% sanity band
+	got, err = feed.validatePrice(context.Background(), "tether", big.NewInt(107))
+	assert.NoError(t, err)
+	assert.False(t, got)
+
+	// Outside the absolute limits
+	_, err = feed.validatePrice(context.Background(), "tether", big.NewInt(89))
+	assert.Error(t, err)
+
+	_, err = feed.validatePrice(context.Background(), "tether", big.NewInt(111))
+	assert.Error(t, err)
+}
+
+func TestValidatePrice_ReferenceUnavailable(t *testing.T) {
+	mockPricer := new(MockReferencePricer)
+	mockPricer.On("ReferencePrice", "bitcoin").Return(pricefeed.Decimal{}, ErrNoReference)
+
+	feed := &SepoliaPriceFeed{
+		cfg:           testConfig(),
+		onChainPrices: make(map[string]*big.Int),
+		reference:     mockPricer,
+	}
+
+	// By default the write is skipped
+	got, err := feed.validatePrice(context.Background(), "bitcoin", big.NewInt(3000000))
+	assert.ErrorIs(t, err, ErrNoReference)
+	assert.False(t, got)
+
+	// The allow policy writes without the sanity check
+	feed.cfg.ReferenceUnavailable = ReferenceAllow
+
+	got, err = feed.validatePrice(context.Background(), "bitcoin", big.NewInt(3000000))
+	assert.NoError(t, err)
+	assert.True(t, got)
+
+	// Without a reference there is no sanity check at all
+	feed.reference = nil
+
+	got, err = feed.validatePrice(context.Background(), "bitcoin", big.NewInt(9000000))
+	assert.NoError(t, err)
+	assert.True(t, got)
+}
+
+func TestOnChainPrices_Decimals(t *testing.T) {
+	feed := &SepoliaPriceFeed{
+		cfg:           testConfig(),
+		onChainPrices: make(map[string]*big.Int),
+	}
+
+	feed.cfg.TokenDecimals = map[string]uint8{"shiba-inu": 8}
+
+	feed.setOnChainPrice("bitcoin", big.NewInt(3000012))
+	feed.setOnChainPrice("shiba-inu", big.NewInt(1234))
+
+	prices := feed.OnChainPrices()
+	assert.Equal(t, "30000.12", prices["bitcoin"].String())
+	assert.Equal(t, "0.00001234", prices["shiba-inu"].String())
+}
+
 func TestWriteToChain(t *testing.T) {
 	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
 	feed := &SepoliaPriceFeed{
+		cfg:           testConfig(),
 		contract:      mockContract,
-		onChainPrices: make(map[string]float64),
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
 		auth:          &bind.TransactOpts{},
+		nonces:        newNonceManager(mockBackend, common.Address{}),
+		pollInterval:  10 * time.Millisecond,
 	}
 
+	mockBackend.On("TransactionReceipt", mock.Anything).Return(minedReceipt(1), nil)
+	mockBackend.On("BlockNumber").Return(uint64(1), nil)
+	mockBackend.On("PendingNonceAt", common.Address{}).Return(uint64(0), nil)
+
 	tests := []struct {
 		name    string
 		symbol  string
@@ -274,7 +543,7 @@ func TestWriteToChain(t *testing.T) {
 					Return(mockTx, nil).Once()
 			}
 
-			err := feed.writeToChain(ctx, tt.symbol, tt.price)
+			err := feed.writeToChain(ctx, tt.symbol, big.NewInt(int64(tt.price*100)))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("writeToChain() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -282,8 +551,11 @@ func TestWriteToChain(t *testing.T) {
 			}
 
 			if !tt.wantErr {
-				// Verify price was cached
-				assert.Equal(t, tt.price, feed.onChainPrices[tt.symbol])
+				// The cache is only updated once the write is confirmed
+				feed.trackers.Wait()
+
+				assert.Equal(t, tt.price, feed.OnChainPrices()[tt.symbol].Float64())
+				assert.Equal(t, TxMined, feed.WriteStatuses()[tt.symbol].Status)
 			}
 		})
 	}
@@ -293,14 +565,19 @@ func TestWriteToChain(t *testing.T) {
 
 func TestWritePricesToChain(t *testing.T) {
 	mockContract := new(MockContract)
-	mockPricer := new(MockChainlinkPricer)
+	mockPricer := new(MockReferencePricer)
+	mockBackend := new(MockTxBackend)
 	feed := &SepoliaPriceFeed{
+		cfg:      testConfig(),
 		contract: mockContract,
-		onChainPrices: map[string]float64{
-			"bitcoin": 30000.00,
+		backend:  mockBackend,
+		onChainPrices: map[string]*big.Int{
+			"bitcoin": big.NewInt(3000000),
 		},
-		auth:            &bind.TransactOpts{},
-		chainlinkPricer: mockPricer,
+		auth:         &bind.TransactOpts{},
+		nonces:       newNonceManager(mockBackend, common.Address{}),
+		reference:    mockPricer,
+		pollInterval: 10 * time.Millisecond,
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -318,7 +595,10 @@ func TestWritePricesToChain(t *testing.T) {
 	// Set up mock expectations
 	mockContract.On("Set", mock.Anything, "bitcoin", big.NewInt(3100000)). // $31,000.00
 										Return(mockTx, nil)
-	mockPricer.On("getChainlinkPrice", "bitcoin").Return(int64(31000), nil)
+	mockPricer.On("ReferencePrice", "bitcoin").Return(pricefeed.DecimalFromInt(31000), nil)
+	mockBackend.On("TransactionReceipt", mockTx.Hash()).Return(minedReceipt(1), nil)
+	mockBackend.On("BlockNumber").Return(uint64(1), nil)
+	mockBackend.On("PendingNonceAt", common.Address{}).Return(uint64(0), nil)
 
 	in := make(chan []pricefeed.Price, 1)
 
@@ -327,7 +607,7 @@ func TestWritePricesToChain(t *testing.T) {
 
 	// Send test prices
 	testPrices := []pricefeed.Price{
-		{Symbol: "bitcoin", USD: 31000.00}, // Valid price
+		{Symbol: "bitcoin", USD: pricefeed.MustDecimal("31000.00")}, // Valid price
 	}
 	in <- testPrices
 
@@ -340,5 +620,102 @@ func TestWritePricesToChain(t *testing.T) {
 	mockPricer.AssertExpectations(t)
 
 	// Verify the cache was updated for the valid price
-	assert.Equal(t, 31000.00, feed.onChainPrices["bitcoin"])
+	assert.Equal(t, "31000", feed.OnChainPrices()["bitcoin"].String())
+}
+
+func TestWritePricesToChain_WaitsForPendingOnClose(t *testing.T) {
+	mockContract := new(MockContract)
+	mockPricer := new(MockReferencePricer)
+	mockBackend := new(MockTxBackend)
+	feed := &SepoliaPriceFeed{
+		cfg:           testConfig(),
+		contract:      mockContract,
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+		auth:          &bind.TransactOpts{},
+		nonces:        newNonceManager(mockBackend, common.Address{}),
+		reference:     mockPricer,
+		pollInterval:  10 * time.Millisecond,
+	}
+
+	mockTx := types.NewTransaction(1, common.Address{}, big.NewInt(0), 0, big.NewInt(0), nil)
+
+	mockContract.On("Set", mock.Anything, "bitcoin", big.NewInt(3100000)).Return(mockTx, nil)
+	mockPricer.On("ReferencePrice", "bitcoin").Return(pricefeed.DecimalFromInt(31000), nil)
+	mockBackend.On("TransactionReceipt", mockTx.Hash()).Return(minedReceipt(1), nil)
+	mockBackend.On("BlockNumber").Return(uint64(1), nil)
+	mockBackend.On("PendingNonceAt", common.Address{}).Return(uint64(0), nil)
+
+	in := make(chan []pricefeed.Price, 1)
+	in <- []pricefeed.Price{{Symbol: "bitcoin", USD: pricefeed.MustDecimal("31000.00")}}
+	close(in)
+
+	done := make(chan struct{})
+
+	go func() {
+		feed.WritePricesToChain(context.Background(), in)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("WritePricesToChain did not return after input was closed")
+	}
+
+	mockBackend.AssertExpectations(t)
+	assert.Equal(t, TxMined, feed.WriteStatuses()["bitcoin"].Status)
+	assert.Equal(t, "31000", feed.OnChainPrices()["bitcoin"].String())
+}
+
+func TestWritePricesToChain_SkipsWhileWritePending(t *testing.T) {
+	mockContract := new(MockContract)
+	mockPricer := new(MockReferencePricer)
+	mockBackend := new(MockTxBackend)
+
+	cfg := testConfig()
+	cfg.TxTimeout = 200 * time.Millisecond
+
+	feed := &SepoliaPriceFeed{
+		cfg:           cfg,
+		contract:      mockContract,
+		backend:       mockBackend,
+		onChainPrices: map[string]*big.Int{"bitcoin": big.NewInt(3000000)},
+		auth:          &bind.TransactOpts{},
+		nonces:        newNonceManager(mockBackend, common.Address{}),
+		reference:     mockPricer,
+		pollInterval:  10 * time.Millisecond,
+	}
+
+	mockTx := types.NewTransaction(1, common.Address{}, big.NewInt(0), 0, big.NewInt(0), nil)
+
+	// The write is never mined while the batches arrive
+	mockContract.On("Set", mock.Anything, "bitcoin", big.NewInt(3100000)).Return(mockTx, nil).Once()
+	mockPricer.On("ReferencePrice", "bitcoin").Return(pricefeed.DecimalFromInt(31000), nil)
+	mockBackend.On("TransactionReceipt", mockTx.Hash()).Return(nil, ethereum.NotFound)
+	mockBackend.On("TransactionByHash", mockTx.Hash()).Return(mockTx, true, nil)
+	mockBackend.On("PendingNonceAt", common.Address{}).Return(uint64(0), nil)
+
+	in := make(chan []pricefeed.Price)
+	done := make(chan struct{})
+
+	go func() {
+		feed.WritePricesToChain(context.Background(), in)
+		close(done)
+	}()
+
+	in <- []pricefeed.Price{{Symbol: "bitcoin", USD: pricefeed.MustDecimal("31000")}}
+	in <- []pricefeed.Price{{Symbol: "bitcoin", USD: pricefeed.MustDecimal("31000")}}
+	in <- []pricefeed.Price{{Symbol: "bitcoin", USD: pricefeed.MustDecimal("31200")}}
+	close(in)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("WritePricesToChain did not return after input was closed")
+	}
+
+	mockContract.AssertNumberOfCalls(t, "Set", 1)
+	assert.Equal(t, TxPending, feed.WriteStatuses()["bitcoin"].Status)
+	assert.Nil(t, feed.inFlightPrice("bitcoin"))
 }
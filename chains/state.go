@@ -0,0 +1,210 @@
+package chains
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// State is the event processing progress kept across restarts
+type State struct {
+	LastBlock uint64                 `json:"lastBlock"` // Highest block whose PriceChanged events were applied
+	Writes    map[string]StoredWrite `json:"writes"`    // symbol -> last price write
+	Prices    map[string]StoredPrice `json:"prices"`    // symbol -> last confirmed on-chain price
+}
+
+// StoredWrite is the persisted outcome of the last price write of a token
+type StoredWrite struct {
+	TxHash      common.Hash `json:"txHash"`
+	Price       *big.Int    `json:"price"` // On-chain fixed-point price
+	Status      TxStatus    `json:"status"`
+	BlockNumber uint64      `json:"blockNumber,omitempty"`
+}
+
+// StoredPrice is a persisted on-chain price
+type StoredPrice struct {
+	Price     *big.Int  `json:"price"`     // On-chain fixed-point price
+	UpdatedAt time.Time `json:"updatedAt"` // Timestamp of the last PriceChanged event, zero if unknown
+}
+
+// StateStore loads and saves State
+type StateStore interface {
+	Load() (State, error)
+	Save(state State) error
+}
+
+// FileStateStore keeps State in a JSON file. Saves go through a temporary file and a rename,
+// so a crash leaves either the previous or the new state on disk.
+type FileStateStore struct {
+	path string
+}
+
+// NewFileStateStore creates a store backed by the file at path
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{path: path}
+}
+
+// Load reads the saved state, returning an empty State if nothing was saved yet
+func (f *FileStateStore) Load() (State, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return State{}, nil
+	}
+
+	if err != nil {
+		return State{}, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("failed to parse state file %s: %w", f.path, err)
+	}
+
+	return state, nil
+}
+
+// Save atomically replaces the saved state
+func (f *FileStateStore) Save(state State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+
+	// Only removes anything when the rename did not happen
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to sync state file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+
+	return nil
+}
+
+// restoreState loads the saved state into the cache, write results and last processed block.
+// Writes that were still pending are restored as TxUnknown, as no tracker follows them anymore.
+func (s *SepoliaPriceFeed) restoreState() error {
+	if s.state == nil {
+		return nil
+	}
+
+	state, err := s.state.Load()
+	if err != nil {
+		return err
+	}
+
+	s.pricesMu.Lock()
+
+	s.lastBlock = state.LastBlock
+
+	for symbol, p := range state.Prices {
+		if p.Price == nil {
+			continue
+		}
+
+		s.onChainPrices[symbol] = p.Price
+
+		if !p.UpdatedAt.IsZero() {
+			s.updatedAt[symbol] = p.UpdatedAt
+		}
+	}
+
+	s.pricesMu.Unlock()
+
+	for symbol, w := range state.Writes {
+		status := w.Status
+
+		// Nothing tracks a write sent by the previous process, so its outcome is unknown
+		if status == TxPending {
+			log.Printf("⚠️ %s transaction %s was pending before the restart and is no longer tracked",
+				symbol, w.TxHash.Hex())
+
+			status = TxUnknown
+		}
+
+		s.recordWrite(WriteResult{
+			Symbol:      symbol,
+			Price:       w.Price,
+			TxHash:      w.TxHash,
+			Status:      status,
+			BlockNumber: w.BlockNumber,
+		})
+	}
+
+	if state.LastBlock > 0 {
+		log.Printf("💾 Restored state at block %d with %d prices", state.LastBlock, len(state.Prices))
+	}
+
+	return nil
+}
+
+// snapshot returns the current state
+func (s *SepoliaPriceFeed) snapshot() State {
+	state := State{
+		Writes: make(map[string]StoredWrite),
+		Prices: make(map[string]StoredPrice),
+	}
+
+	s.pricesMu.RLock()
+
+	state.LastBlock = s.lastBlock
+
+	for symbol, price := range s.onChainPrices {
+		state.Prices[symbol] = StoredPrice{Price: price, UpdatedAt: s.updatedAt[symbol]}
+	}
+
+	s.pricesMu.RUnlock()
+
+	for symbol, result := range s.WriteStatuses() {
+		state.Writes[symbol] = StoredWrite{
+			TxHash:      result.TxHash,
+			Price:       result.Price,
+			Status:      result.Status,
+			BlockNumber: result.BlockNumber,
+		}
+	}
+
+	return state
+}
+
+// saveState persists the current state. Failures are logged, the in-memory state stays authoritative.
+func (s *SepoliaPriceFeed) saveState() {
+	if s.state == nil {
+		return
+	}
+
+	// Snapshots are taken under the save lock so an older one never overwrites a newer one
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if err := s.state.Save(s.snapshot()); err != nil {
+		log.Printf("⚠️ Failed to save state: %v", err)
+	}
+}
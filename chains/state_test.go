@@ -0,0 +1,115 @@
+package chains
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+func TestFileStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store := NewFileStateStore(path)
+
+	// Nothing saved yet
+	state, err := store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, State{}, state)
+
+	saved := State{
+		LastBlock: 120,
+		Writes: map[string]StoredWrite{
+			"bitcoin": {TxHash: common.HexToHash("0x01"), Price: big.NewInt(3000000), Status: TxMined, BlockNumber: 118},
+		},
+		Prices: map[string]StoredPrice{
+			"bitcoin": {Price: big.NewInt(3000000), UpdatedAt: time.Unix(1_700_000_000, 0).UTC()},
+		},
+	}
+
+	assert.NoError(t, store.Save(saved))
+
+	state, err = store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, saved, state)
+
+	// Saving leaves no temporary files behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+	_, err = store.Load()
+	assert.Error(t, err)
+}
+
+func TestStateRestoredAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	feed := &SepoliaPriceFeed{
+		cfg:           testConfig(),
+		onChainPrices: make(map[string]*big.Int),
+		state:         NewFileStateStore(path),
+	}
+
+	ctx := context.Background()
+	out := make(chan pricefeed.Price, 10)
+
+	assert.NoError(t, feed.handleEvent(ctx, reorgEvent(120, 3000000, false), out))
+
+	feed.recordWrite(WriteResult{
+		Symbol: "ethereum",
+		Price:  big.NewInt(200012),
+		TxHash: common.HexToHash("0x02"),
+		Status: TxPending,
+	})
+	feed.saveState()
+
+	// A new process picks up where the last one stopped
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+
+	cfg := testConfig()
+	cfg.BackfillFromBlock = 1
+
+	restarted := &SepoliaPriceFeed{
+		cfg:           cfg,
+		contract:      mockContract,
+		backend:       mockBackend,
+		onChainPrices: make(map[string]*big.Int),
+		updatedAt:     make(map[string]time.Time),
+		state:         NewFileStateStore(path),
+	}
+
+	assert.NoError(t, restarted.restoreState())
+	assert.Equal(t, uint64(120), restarted.LastBlock())
+	assert.Equal(t, "30000", restarted.OnChainPrices()["bitcoin"].String())
+	assert.Equal(t, int64(1_700_000_120), restarted.updatedAt["bitcoin"].Unix())
+
+	// The pending write is no longer tracked, so it is not reported as pending
+	write := restarted.WriteStatuses()["ethereum"]
+	assert.Equal(t, TxUnknown, write.Status)
+	assert.Equal(t, common.HexToHash("0x02"), write.TxHash)
+	assert.Equal(t, "200012", write.Price.String())
+
+	// Only the missing range is backfilled
+	mockBackend.On("BlockNumber").Return(uint64(130), nil)
+	mockContract.On("FilterPriceChanged", uint64(121), uint64(130)).Return(priceChangedIterator(t,
+		priceChangedLog{block: 125, symbol: "bitcoin", price: 3100000, timestamp: 1_700_000_125},
+	), nil).Once()
+
+	assert.NoError(t, restarted.Backfill(ctx, out))
+	mockContract.AssertExpectations(t)
+
+	state, err := NewFileStateStore(path).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(130), state.LastBlock)
+	assert.Equal(t, "3100000", state.Prices["bitcoin"].Price.String())
+}
@@ -0,0 +1,343 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// defaultTxTimeout bounds how long a write is tracked before it is reported as pending or dropped
+	defaultTxTimeout = 5 * time.Minute
+	// confirmationPollInterval is how often the chain head is checked while waiting for confirmations
+	confirmationPollInterval = 4 * time.Second
+	// dropCheckTimeout bounds the lookup used to tell pending transactions from dropped ones
+	dropCheckTimeout = 10 * time.Second
+	// drainShare is the percentage of ShutdownTimeout the writer waits for pending transactions
+	drainShare = 80
+)
+
+// TxStatus describes the lifecycle state of a price write transaction
+type TxStatus string
+
+const (
+	TxPending  TxStatus = "pending"  // Sent but not yet mined at the required depth
+	TxMined    TxStatus = "mined"    // Mined successfully with the required confirmations
+	TxReverted TxStatus = "reverted" // Mined but the contract call failed
+	TxDropped  TxStatus = "dropped"  // No longer known to the node
+	TxUnknown  TxStatus = "unknown"  // Pending before a restart and no longer tracked
+)
+
+// WriteResult reports the outcome of a single price write
+type WriteResult struct {
+	Symbol      string
+	Price       *big.Int // On-chain fixed-point price
+	TxHash      common.Hash
+	Status      TxStatus
+	BlockNumber uint64
+	Err         error
+}
+
+// TxBackend is the subset of the RPC client needed to track sent transactions
+type TxBackend interface {
+	bind.DeployBackend
+	BlockNumber(ctx context.Context) (uint64, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+}
+
+// trackWrite follows a sent transaction until it is confirmed, reverted, dropped or times out,
+// and only then updates the on-chain price cache
+func (s *SepoliaPriceFeed) trackWrite(ctx context.Context, symbol string, price *big.Int, tx *types.Transaction) {
+	defer s.trackers.Done()
+
+	timeout := s.cfg.TxTimeout
+	if timeout <= 0 {
+		timeout = defaultTxTimeout
+	}
+
+	// Tracking outlives cancellation of the writer so shutdown can drain pending writes
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	result := s.confirm(ctx, symbol, price, tx)
+	result.Symbol = symbol
+	result.Price = price
+
+	s.recordWrite(result)
+
+	// The write result and a confirmed price are saved together
+	defer s.saveState()
+
+	// Validation compares against the cache again once it holds a confirmed price
+	defer s.clearInFlight(symbol, price)
+
+	switch result.Status {
+	case TxMined:
+		s.setOnChainPrice(symbol, price)
+
+		log.Printf("⛏️ %s price %s confirmed in block %d (tx %s)", symbol, price, result.BlockNumber, result.TxHash.Hex())
+	case TxReverted:
+		log.Printf("❌ %s write reverted in block %d (tx %s)", symbol, result.BlockNumber, result.TxHash.Hex())
+	case TxDropped:
+		// Later writes are stuck behind the lost nonce until it is reused
+		s.nonces.Reset()
+
+		log.Printf("⚠️ %s write dropped (tx %s): %v", symbol, result.TxHash.Hex(), result.Err)
+	default:
+		log.Printf("⚠️ %s write %s (tx %s): %v", symbol, result.Status, result.TxHash.Hex(), result.Err)
+	}
+}
+
+// confirm waits for tx, or one of its replacements, to be mined and buried under the configured
+// number of confirmations. If it is reorganized out of its block it goes back to waiting for a receipt.
+func (s *SepoliaPriceFeed) confirm(ctx context.Context, symbol string, price *big.Int,
+	tx *types.Transaction,
+) WriteResult {
+	confirmations := s.cfg.Confirmations
+	if confirmations == 0 {
+		confirmations = 1
+	}
+
+	ticker := time.NewTicker(s.confirmationInterval())
+	defer ticker.Stop()
+
+	sent := []*types.Transaction{tx}
+
+	for {
+		receipt, err := s.waitMined(ctx, symbol, price, &sent)
+		if err != nil {
+			latest := sent[len(sent)-1]
+
+			return WriteResult{TxHash: latest.Hash(), Status: s.unminedStatus(latest), Err: err}
+		}
+
+		mined := receiptTx(receipt, sent)
+		result := WriteResult{TxHash: mined.Hash(), BlockNumber: receipt.BlockNumber.Uint64()}
+
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			result.Status = TxReverted
+			result.Err = fmt.Errorf("transaction reverted")
+
+			return result
+		}
+
+		target := result.BlockNumber + confirmations - 1
+
+		for {
+			head, err := s.backend.BlockNumber(ctx)
+			if err == nil && head >= target {
+				break
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				result.Status = TxPending
+				result.Err = fmt.Errorf("waiting for %d confirmations: %w", confirmations, ctx.Err())
+
+				return result
+			}
+		}
+
+		// Make sure the receipt is still canonical at the required depth
+		current, err := s.backend.TransactionReceipt(ctx, mined.Hash())
+		if err == nil && current.BlockHash == receipt.BlockHash {
+			result.Status = TxMined
+
+			return result
+		}
+
+		log.Printf("🔀 Transaction %s moved out of block %d, waiting again", mined.Hash().Hex(), result.BlockNumber)
+	}
+}
+
+// waitMined waits for any of the sent transactions to be mined. When the latest one stays
+// pending longer than GasStuckTimeout it is replaced with higher fees and appended to sent.
+func (s *SepoliaPriceFeed) waitMined(ctx context.Context, symbol string, price *big.Int,
+	sent *[]*types.Transaction,
+) (*types.Receipt, error) {
+	for {
+		latest := (*sent)[len(*sent)-1]
+
+		waitCtx, cancel := ctx, context.CancelFunc(func() {})
+		if s.cfg.GasStuckTimeout > 0 {
+			waitCtx, cancel = context.WithTimeout(ctx, s.cfg.GasStuckTimeout)
+		}
+
+		receipt, err := bind.WaitMined(waitCtx, s.backend, latest)
+
+		cancel()
+
+		if err == nil {
+			return receipt, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		// An earlier attempt may have been mined while we waited on its replacement
+		for _, prev := range (*sent)[:len(*sent)-1] {
+			if receipt, err := s.backend.TransactionReceipt(ctx, prev.Hash()); err == nil {
+				return receipt, nil
+			}
+		}
+
+		replacement, err := s.replace(ctx, symbol, price, latest)
+		if err != nil {
+			log.Printf("⚠️ Could not replace stuck %s write %s: %v", symbol, latest.Hash().Hex(), err)
+
+			continue
+		}
+
+		log.Printf("⛽ Replaced stuck %s write %s with %s", symbol, latest.Hash().Hex(), replacement.Hash().Hex())
+
+		*sent = append(*sent, replacement)
+	}
+}
+
+// replace resends a stuck write with the same nonce and bumped fees
+func (s *SepoliaPriceFeed) replace(ctx context.Context, symbol string, price *big.Int,
+	stuck *types.Transaction,
+) (*types.Transaction, error) {
+	var tip, feeCap *big.Int
+
+	if s.gas != nil {
+		var err error
+
+		tip, feeCap, err = s.gas.Fees(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tip, feeCap, err := bumpFees(stuck, tip, feeCap, s.cfg.GasBumpPercent, s.maxFee)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sendPrice(ctx, symbol, price, stuck.Nonce(), tip, feeCap)
+}
+
+// receiptTx returns the sent transaction a receipt belongs to
+func receiptTx(receipt *types.Receipt, sent []*types.Transaction) *types.Transaction {
+	for _, tx := range sent {
+		if tx.Hash() == receipt.TxHash {
+			return tx
+		}
+	}
+
+	return sent[len(sent)-1]
+}
+
+// unminedStatus distinguishes a transaction still waiting in the mempool from one the node has dropped
+func (s *SepoliaPriceFeed) unminedStatus(tx *types.Transaction) TxStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), dropCheckTimeout)
+	defer cancel()
+
+	_, _, err := s.backend.TransactionByHash(ctx, tx.Hash())
+	if errors.Is(err, ethereum.NotFound) {
+		return TxDropped
+	}
+
+	return TxPending
+}
+
+// recordWrite stores the latest write result for a symbol
+func (s *SepoliaPriceFeed) recordWrite(result WriteResult) {
+	s.writesMu.Lock()
+	defer s.writesMu.Unlock()
+
+	if s.writes == nil {
+		s.writes = make(map[string]WriteResult)
+	}
+
+	s.writes[result.Symbol] = result
+}
+
+// setInFlight records price as the newest write of symbol being tracked
+func (s *SepoliaPriceFeed) setInFlight(symbol string, price *big.Int) {
+	s.writesMu.Lock()
+	defer s.writesMu.Unlock()
+
+	if s.inFlight == nil {
+		s.inFlight = make(map[string]*big.Int)
+	}
+
+	s.inFlight[symbol] = price
+}
+
+// clearInFlight forgets a finished write unless a newer write of the symbol replaced it
+func (s *SepoliaPriceFeed) clearInFlight(symbol string, price *big.Int) {
+	s.writesMu.Lock()
+	defer s.writesMu.Unlock()
+
+	if s.inFlight[symbol] == price {
+		delete(s.inFlight, symbol)
+	}
+}
+
+// inFlightPrice returns the price of the newest tracked write of symbol, or nil when none is in flight
+func (s *SepoliaPriceFeed) inFlightPrice(symbol string) *big.Int {
+	s.writesMu.Lock()
+	defer s.writesMu.Unlock()
+
+	return s.inFlight[symbol]
+}
+
+// WriteStatuses returns the latest write result for every symbol
+func (s *SepoliaPriceFeed) WriteStatuses() map[string]WriteResult {
+	s.writesMu.Lock()
+	defer s.writesMu.Unlock()
+
+	statuses := make(map[string]WriteResult, len(s.writes))
+	for symbol, result := range s.writes {
+		statuses[symbol] = result
+	}
+
+	return statuses
+}
+
+// drainTimeout is the share of ShutdownTimeout spent waiting for pending transactions. The shutdown
+// of main runs against the whole ShutdownTimeout from about the same moment, so the writer must give
+// up first for a drain that takes most of the budget to still count as a clean shutdown.
+func (s *SepoliaPriceFeed) drainTimeout() time.Duration {
+	return s.cfg.ShutdownTimeout * drainShare / 100
+}
+
+// waitPending waits for every tracked write to finish, up to timeout
+func (s *SepoliaPriceFeed) waitPending(timeout time.Duration) {
+	done := make(chan struct{})
+
+	go func() {
+		s.trackers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	log.Printf("⏳ Waiting up to %s for pending transactions", timeout)
+
+	select {
+	case <-done:
+		log.Println("✅ All pending transactions settled")
+	case <-time.After(timeout):
+		for symbol, result := range s.WriteStatuses() {
+			if result.Status == TxPending {
+				log.Printf("⚠️ %s transaction %s still pending at shutdown", symbol, result.TxHash.Hex())
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package chains
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sljivkov/dectek/config"
+)
+
+func TestTrackWrite(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(b *MockTxBackend, tx *types.Transaction)
+		wantStatus TxStatus
+		wantCached bool
+	}{
+		{
+			name: "mined with confirmations",
+			setup: func(b *MockTxBackend, tx *types.Transaction) {
+				b.On("TransactionReceipt", tx.Hash()).Return(minedReceipt(10), nil)
+				b.On("BlockNumber").Return(uint64(11), nil)
+			},
+			wantStatus: TxMined,
+			wantCached: true,
+		},
+		{
+			name: "reverted",
+			setup: func(b *MockTxBackend, tx *types.Transaction) {
+				receipt := minedReceipt(10)
+				receipt.Status = types.ReceiptStatusFailed
+
+				b.On("TransactionReceipt", tx.Hash()).Return(receipt, nil)
+			},
+			wantStatus: TxReverted,
+		},
+		{
+			name: "dropped",
+			setup: func(b *MockTxBackend, tx *types.Transaction) {
+				b.On("TransactionReceipt", tx.Hash()).Return(nil, ethereum.NotFound)
+				b.On("TransactionByHash", tx.Hash()).Return(nil, false, ethereum.NotFound)
+			},
+			wantStatus: TxDropped,
+		},
+		{
+			name: "still pending",
+			setup: func(b *MockTxBackend, tx *types.Transaction) {
+				b.On("TransactionReceipt", tx.Hash()).Return(nil, ethereum.NotFound)
+				b.On("TransactionByHash", tx.Hash()).Return(tx, true, nil)
+			},
+			wantStatus: TxPending,
+		},
+		{
+			name: "not enough confirmations",
+			setup: func(b *MockTxBackend, tx *types.Transaction) {
+				b.On("TransactionReceipt", tx.Hash()).Return(minedReceipt(10), nil)
+				b.On("BlockNumber").Return(uint64(10), nil)
+			},
+			wantStatus: TxPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := new(MockTxBackend)
+			feed := &SepoliaPriceFeed{
+				cfg:           config.Config{Confirmations: 2, TxTimeout: 200 * time.Millisecond, Decimals: 2},
+				backend:       mockBackend,
+				onChainPrices: make(map[string]*big.Int),
+				nonces:        newNonceManager(mockBackend, common.Address{}),
+				pollInterval:  10 * time.Millisecond,
+			}
+
+			tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), nil)
+			tt.setup(mockBackend, tx)
+
+			feed.trackers.Add(1)
+			feed.trackWrite(context.Background(), "bitcoin", big.NewInt(3000000), tx)
+
+			result := feed.WriteStatuses()["bitcoin"]
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tx.Hash(), result.TxHash)
+
+			if tt.wantCached {
+				assert.Equal(t, "30000", feed.OnChainPrices()["bitcoin"].String())
+			} else {
+				assert.NotContains(t, feed.OnChainPrices(), "bitcoin")
+			}
+		})
+	}
+}
+
+func TestTrackWriteReplacesStuckTransaction(t *testing.T) {
+	mockContract := new(MockContract)
+	mockBackend := new(MockTxBackend)
+	feed := &SepoliaPriceFeed{
+		cfg: config.Config{
+			Decimals:        2,
+			Confirmations:   1,
+			TxTimeout:       time.Second,
+			GasStuckTimeout: 50 * time.Millisecond,
+			GasBumpPercent:  15,
+		},
+		contract:      mockContract,
+		backend:       mockBackend,
+		auth:          &bind.TransactOpts{},
+		onChainPrices: make(map[string]*big.Int),
+		nonces:        newNonceManager(mockBackend, common.Address{}),
+		pollInterval:  10 * time.Millisecond,
+	}
+
+	stuck := types.NewTx(&types.DynamicFeeTx{Nonce: 7, GasTipCap: gwei(2), GasFeeCap: gwei(20)})
+	replacement := types.NewTx(&types.DynamicFeeTx{Nonce: 7, GasTipCap: gwei(3), GasFeeCap: gwei(23)})
+
+	receipt := minedReceipt(5)
+	receipt.TxHash = replacement.Hash()
+
+	mockBackend.On("TransactionReceipt", stuck.Hash()).Return(nil, ethereum.NotFound)
+	mockBackend.On("TransactionReceipt", replacement.Hash()).Return(receipt, nil)
+	mockBackend.On("BlockNumber").Return(uint64(5), nil)
+
+	mockContract.On("Set", mock.MatchedBy(func(opts *bind.TransactOpts) bool {
+		return opts.Nonce.Uint64() == 7 && opts.GasFeeCap.Cmp(gwei(23)) == 0
+	}), "bitcoin", big.NewInt(3000000)).Return(replacement, nil).Once()
+
+	feed.trackers.Add(1)
+	feed.trackWrite(context.Background(), "bitcoin", big.NewInt(3000000), stuck)
+
+	result := feed.WriteStatuses()["bitcoin"]
+	assert.Equal(t, TxMined, result.Status)
+	assert.Equal(t, replacement.Hash(), result.TxHash)
+	assert.Equal(t, "30000", feed.OnChainPrices()["bitcoin"].String())
+	mockContract.AssertExpectations(t)
+}
+
+func TestDrainTimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.ShutdownTimeout = 30 * time.Second
+
+	feed := &SepoliaPriceFeed{cfg: cfg}
+
+	// The writer gives up before main's shutdown deadline
+	assert.Equal(t, 24*time.Second, feed.drainTimeout())
+	assert.Less(t, feed.drainTimeout(), cfg.ShutdownTimeout)
+}
@@ -0,0 +1,231 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// defaultTWAPInterval is used when no polling interval is configured
+const defaultTWAPInterval = time.Minute
+
+//nolint:lll
+const uniswapV3PoolABI = `[{"inputs":[{"internalType":"uint32[]","name":"secondsAgos","type":"uint32[]"}],"name":"observe","outputs":[{"internalType":"int56[]","name":"tickCumulatives","type":"int56[]"},{"internalType":"uint160[]","name":"secondsPerLiquidityCumulativeX128s","type":"uint160[]"}],"stateMutability":"view","type":"function"}]`
+
+// UniswapPool describes a Uniswap v3 pool used to price a token against a USD stablecoin
+type UniswapPool struct {
+	Address        common.Address
+	Token0Decimals int
+	Token1Decimals int
+	BaseIsToken0   bool // true when the priced token is token0 and the stablecoin is token1
+}
+
+// UniswapTWAP implements pricefeed.PriceProvider using Uniswap v3 pool observations
+type UniswapTWAP struct {
+	caller   bind.ContractCaller
+	pools    map[string]UniswapPool
+	window   time.Duration
+	interval time.Duration
+	poolABI  abi.ABI
+}
+
+// NewUniswapTWAP creates a new TWAP provider over the given pools
+func NewUniswapTWAP(
+	caller bind.ContractCaller,
+	pools map[string]UniswapPool,
+	window time.Duration,
+	interval time.Duration,
+) (*UniswapTWAP, error) {
+	if window < time.Second {
+		return nil, fmt.Errorf("TWAP window must be at least one second, got %s", window)
+	}
+
+	if interval <= 0 {
+		interval = defaultTWAPInterval
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(uniswapV3PoolABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Uniswap v3 pool ABI: %w", err)
+	}
+
+	return &UniswapTWAP{
+		caller:   caller,
+		pools:    pools,
+		window:   window,
+		interval: interval,
+		poolABI:  parsedABI,
+	}, nil
+}
+
+// ParseUniswapPools parses a comma-separated list of token:pool:decimals0:decimals1:baseIndex
+// entries, where baseIndex is 0 or 1 and selects which pool token is being priced
+func ParseUniswapPools(spec string) (map[string]UniswapPool, error) {
+	pools := make(map[string]UniswapPool)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, ":")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("invalid Uniswap pool entry %q", entry)
+		}
+
+		if !common.IsHexAddress(fields[1]) {
+			return nil, fmt.Errorf("invalid Uniswap pool address %q", fields[1])
+		}
+
+		dec0, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid token0 decimals in %q: %w", entry, err)
+		}
+
+		dec1, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid token1 decimals in %q: %w", entry, err)
+		}
+
+		if fields[4] != "0" && fields[4] != "1" {
+			return nil, fmt.Errorf("invalid base token index in %q", entry)
+		}
+
+		pools[fields[0]] = UniswapPool{
+			Address:        common.HexToAddress(fields[1]),
+			Token0Decimals: dec0,
+			Token1Decimals: dec1,
+			BaseIsToken0:   fields[4] == "0",
+		}
+	}
+
+	return pools, nil
+}
+
+// getTWAPPrice returns the time-weighted average USD price of a token over the configured window
+func (u *UniswapTWAP) getTWAPPrice(ctx context.Context, symbol string) (float64, error) {
+	pool, ok := u.pools[symbol]
+	if !ok {
+		return 0, fmt.Errorf("no Uniswap pool configured for %s", symbol)
+	}
+
+	window := uint32(u.window / time.Second)
+
+	contract := bind.NewBoundContract(pool.Address, u.poolABI, u.caller, nil, nil)
+
+	var out []any
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "observe", []uint32{window, 0}); err != nil {
+		return 0, fmt.Errorf("failed to observe Uniswap pool for %s: %w", symbol, err)
+	}
+
+	tickCumulatives, ok := out[0].([]*big.Int)
+	if !ok || len(tickCumulatives) != 2 {
+		return 0, fmt.Errorf("invalid observation data received from Uniswap pool")
+	}
+
+	tick := averageTick(tickCumulatives[0], tickCumulatives[1], int64(window))
+
+	price := tickToPrice(tick, pool.Token0Decimals, pool.Token1Decimals)
+	if !pool.BaseIsToken0 {
+		price = 1 / price
+	}
+
+	log.Printf("🦄 Uniswap TWAP %s: %.6f (tick %d)", symbol, price, tick)
+
+	return price, nil
+}
+
+// Name returns "uniswap"
+func (u *UniswapTWAP) Name() string {
+	return "uniswap"
+}
+
+// ReferencePrice returns the TWAP price of a token, implementing ReferencePricer
+func (u *UniswapTWAP) ReferencePrice(ctx context.Context, symbol string) (pricefeed.Decimal, error) {
+	price, err := u.getTWAPPrice(ctx, symbol)
+	if err != nil {
+		return pricefeed.Decimal{}, err
+	}
+
+	return pricefeed.DecimalFromFloat(price), nil
+}
+
+// averageTick computes the arithmetic mean tick between two tick cumulatives,
+// rounding toward negative infinity like Uniswap's OracleLibrary
+func averageTick(older, newer *big.Int, window int64) int64 {
+	delta := new(big.Int).Sub(newer, older).Int64()
+
+	tick := delta / window
+	if delta < 0 && delta%window != 0 {
+		tick--
+	}
+
+	return tick
+}
+
+// tickToPrice converts a tick to the human-readable price of token0 denominated in token1
+func tickToPrice(tick int64, decimals0, decimals1 int) float64 {
+	return math.Pow(1.0001, float64(tick)) * math.Pow10(decimals0-decimals1)
+}
+
+// getPrices fetches the TWAP price for every configured pool
+func (u *UniswapTWAP) getPrices(ctx context.Context) []pricefeed.Price {
+	prices := make([]pricefeed.Price, 0, len(u.pools))
+
+	for symbol := range u.pools {
+		price, err := u.getTWAPPrice(ctx, symbol)
+		if err != nil {
+			log.Printf("⚠️ %v", err)
+
+			continue
+		}
+
+		prices = append(prices, pricefeed.Price{
+			Symbol: symbol,
+			USD:    pricefeed.DecimalFromFloat(price),
+		})
+	}
+
+	return prices
+}
+
+// UpdatePriceFromApi continuously reads TWAP prices from the configured pools until ctx is canceled
+func (u *UniswapTWAP) UpdatePriceFromApi(ctx context.Context, priceCh chan<- []pricefeed.Price) {
+	log.Printf("📡 Starting Uniswap TWAP price service over %d pools", len(u.pools))
+
+	defer close(priceCh)
+
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+
+	for {
+		if data := u.getPrices(ctx); len(data) > 0 {
+			log.Printf("✅ Successfully read %d TWAP prices from Uniswap", len(data))
+
+			select {
+			case priceCh <- data:
+			case <-ctx.Done():
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Println("🛑 Stopping Uniswap TWAP price service")
+
+			return
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package chains
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockContractCaller implements bind.ContractCaller, answering calls by contract address
+type MockContractCaller struct {
+	mock.Mock
+}
+
+func (m *MockContractCaller) CodeAt(_ context.Context, contract common.Address, _ *big.Int) ([]byte, error) {
+	args := m.Called(contract)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockContractCaller) CallContract(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	args := m.Called(*call.To)
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func TestParseUniswapPools(t *testing.T) {
+	pools, err := ParseUniswapPools(
+		"ethereum:0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640:6:18:1, bitcoin:0x99ac8cA7087fA4A2A1FB6357269965A2014ABc35:8:6:0")
+	assert.NoError(t, err)
+	assert.Len(t, pools, 2)
+	assert.Equal(t, UniswapPool{
+		Address:        common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640"),
+		Token0Decimals: 6,
+		Token1Decimals: 18,
+		BaseIsToken0:   false,
+	}, pools["ethereum"])
+	assert.True(t, pools["bitcoin"].BaseIsToken0)
+
+	_, err = ParseUniswapPools("ethereum:not-an-address:6:18:1")
+	assert.Error(t, err)
+
+	_, err = ParseUniswapPools("ethereum:0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640:6:18:2")
+	assert.Error(t, err)
+}
+
+func TestAverageTick(t *testing.T) {
+	assert.Equal(t, int64(100), averageTick(big.NewInt(1000), big.NewInt(1200), 2))
+	assert.Equal(t, int64(-4), averageTick(big.NewInt(0), big.NewInt(-7), 2))
+	assert.Equal(t, int64(-3), averageTick(big.NewInt(0), big.NewInt(-6), 2))
+}
+
+func TestGetTWAPPrice(t *testing.T) {
+	poolAddr := common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640")
+	mockCaller := new(MockContractCaller)
+
+	twap, err := NewUniswapTWAP(mockCaller, map[string]UniswapPool{
+		"ethereum": {Address: poolAddr, Token0Decimals: 6, Token1Decimals: 18},
+	}, 30*time.Minute, time.Minute)
+	assert.NoError(t, err)
+
+	// Average tick of 200000 over the 1800 second window
+	output, err := twap.poolABI.Methods["observe"].Outputs.Pack(
+		[]*big.Int{big.NewInt(1_000_000), big.NewInt(1_000_000 + 200000*1800)},
+		[]*big.Int{big.NewInt(0), big.NewInt(0)},
+	)
+	assert.NoError(t, err)
+
+	mockCaller.On("CallContract", poolAddr).Return(output, nil)
+
+	price, err := twap.getTWAPPrice(context.Background(), "ethereum")
+	assert.NoError(t, err)
+
+	// token0 is USDC, so the ETH price is the inverse of token0 priced in token1
+	expected := 1e12 / math.Pow(1.0001, 200000)
+	assert.InDelta(t, expected, price, 1e-6)
+
+	_, err = twap.getTWAPPrice(context.Background(), "bitcoin")
+	assert.Error(t, err)
+
+	mockCaller.AssertExpectations(t)
+}
+
+func TestNewUniswapTWAP_InvalidWindow(t *testing.T) {
+	_, err := NewUniswapTWAP(new(MockContractCaller), nil, 0, time.Minute)
+	assert.Error(t, err)
+}
@@ -4,6 +4,8 @@ package config
 import (
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
@@ -17,6 +19,108 @@ type Config struct {
 	Alchemy    string `env:"ALCHEMY" required:"true"`    // Alchemy RPC URL
 	Contract   string `env:"CONTRACT" required:"true"`   // Smart contract address
 	PrivateKey string `env:"PRIVATEKEY" required:"true"` // Private key for transactions
+
+	// Binance ticker price endpoint
+	BinanceUrl      string            `envconfig:"BINANCE_URL" default:"https://api.binance.com/api/v3/ticker/price"`
+	BinanceInterval time.Duration     `envconfig:"BINANCE_INTERVAL" default:"10s"` // Binance polling interval
+	BinancePairs    map[string]string `envconfig:"BINANCE_PAIRS"`                  // Extra token:PAIR mappings
+
+	// Binance mode: "poll" uses the REST ticker, "stream" uses the websocket mini ticker stream
+	BinanceMode      string        `envconfig:"BINANCE_MODE" default:"poll"`
+	BinanceStreamUrl string        `envconfig:"BINANCE_STREAM_URL" default:"wss://stream.binance.com:9443/stream"`
+	StreamDebounce   time.Duration `envconfig:"STREAM_DEBOUNCE" default:"2s"`    // Window for coalescing streamed prices
+	StreamMaxBackoff time.Duration `envconfig:"STREAM_MAX_BACKOFF" default:"1m"` // Maximum delay between reconnects
+
+	// Coinbase spot price endpoint, pairs are appended as /{pair}/spot
+	CoinbaseUrl      string            `envconfig:"COINBASE_URL" default:"https://api.coinbase.com/v2/prices"`
+	CoinbaseInterval time.Duration     `envconfig:"COINBASE_INTERVAL" default:"10s"` // Coinbase polling interval
+	CoinbasePairs    map[string]string `envconfig:"COINBASE_PAIRS"`                  // Extra token:PAIR mappings
+
+	// Kraken ticker endpoint
+	KrakenUrl      string            `envconfig:"KRAKEN_URL" default:"https://api.kraken.com/0/public/Ticker"`
+	KrakenInterval time.Duration     `envconfig:"KRAKEN_INTERVAL" default:"10s"` // Kraken polling interval
+	KrakenPairs    map[string]string `envconfig:"KRAKEN_PAIRS"`                  // Extra token:PAIR mappings
+
+	// Uniswap v3 pools as token:pool:decimals0:decimals1:baseIndex entries, empty disables TWAP pricing
+	UniswapPools    string        `envconfig:"UNISWAP_POOLS"`
+	UniswapWindow   time.Duration `envconfig:"UNISWAP_WINDOW" default:"30m"`  // TWAP averaging window
+	UniswapInterval time.Duration `envconfig:"UNISWAP_INTERVAL" default:"1m"` // TWAP polling interval
+
+	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"` // Maximum time to drain on shutdown
+	Confirmations   uint64        `envconfig:"CONFIRMATIONS" default:"2"`      // Blocks required before a write counts
+	TxTimeout       time.Duration `envconfig:"TX_TIMEOUT" default:"5m"`        // Maximum time to track a sent write
+
+	// Event subscription retries, doubling from RESUBSCRIBE_BACKOFF up to RESUBSCRIBE_MAX_BACKOFF
+	ResubscribeBackoff    time.Duration `envconfig:"RESUBSCRIBE_BACKOFF" default:"1s"`
+	ResubscribeMaxBackoff time.Duration `envconfig:"RESUBSCRIBE_MAX_BACKOFF" default:"1m"`
+
+	// Blocks a PriceChanged event must be buried under before it is applied, 0 applies it immediately
+	EventConfirmations uint64 `envconfig:"EVENT_CONFIRMATIONS"`
+
+	// PriceChanged log polling, used instead of a subscription when ALCHEMY is an http(s) URL
+	EventPollInterval time.Duration `envconfig:"EVENT_POLL_INTERVAL" default:"12s"` // Time between log queries
+	EventPollWindow   uint64        `envconfig:"EVENT_POLL_WINDOW" default:"20"`    // Recent blocks queried again
+
+	// PriceChanged backfill on startup, from the block after the last processed one or BACKFILL_FROM_BLOCK
+	BackfillFromBlock uint64 `envconfig:"BACKFILL_FROM_BLOCK"`               // 0 disables without a processed block
+	BackfillPageSize  uint64 `envconfig:"BACKFILL_PAGE_SIZE" default:"5000"` // Blocks per log query
+
+	ReconcileInterval time.Duration `envconfig:"RECONCILE_INTERVAL" default:"10m"` // Cache vs storage check, 0 disables
+
+	// JSON file keeping the last processed block, last writes and on-chain prices across restarts, empty disables
+	StateFile string `envconfig:"STATE_FILE"`
+
+	// Embedded history of API ticks and PriceChanged events, empty HISTORY_FILE disables
+	HistoryFile           string        `envconfig:"HISTORY_FILE"`
+	HistoryAPIRetention   time.Duration `envconfig:"HISTORY_API_RETENTION" default:"168h"` // 0 keeps API ticks forever
+	HistoryChainRetention time.Duration `envconfig:"HISTORY_CHAIN_RETENTION"`              // 0 keeps events forever
+	HistoryPruneInterval  time.Duration `envconfig:"HISTORY_PRUNE_INTERVAL" default:"1h"`  // Time between prunes
+
+	// Write policy defaults, each has a per-token token:value override map
+	Deviation   float64                  `envconfig:"DEVIATION" default:"0.02"`  // Change needed to write (0.02 = 2%)
+	Deviations  map[string]float64       `envconfig:"DEVIATIONS"`                // Per-token deviation overrides
+	SanityBand  float64                  `envconfig:"SANITY_BAND" default:"0.2"` // Max distance from the reference price
+	SanityBands map[string]float64       `envconfig:"SANITY_BANDS"`              // Per-token sanity band overrides
+	Heartbeat   time.Duration            `envconfig:"HEARTBEAT" default:"1h"`    // Max on-chain price age, 0 disables
+	Heartbeats  map[string]time.Duration `envconfig:"HEARTBEATS"`                // Per-token heartbeat overrides
+	MinPrices   map[string]float64       `envconfig:"MIN_PRICES"`                // Per-token lowest accepted USD price
+	MaxPrices   map[string]float64       `envconfig:"MAX_PRICES"`                // Per-token highest accepted USD price
+
+	// Chainlink reference feeds, looked up per network from CHAINLINK_FEEDS, the feeds file and the Feed Registry
+	ChainlinkNetwork   string            `envconfig:"CHAINLINK_NETWORK"`    // Derived from the chain ID if empty
+	ChainlinkFeedsFile string            `envconfig:"CHAINLINK_FEEDS_FILE"` // JSON file of network -> token -> aggregator
+	ChainlinkFeeds     map[string]string `envconfig:"CHAINLINK_FEEDS"`      // token:aggregator overrides
+	ChainlinkRegistry  string            `envconfig:"CHAINLINK_REGISTRY"`   // Feed Registry address, empty disables
+	ChainlinkBases     map[string]string `envconfig:"CHAINLINK_BASES"`      // token:base denomination for the registry
+
+	// Sanity band references tried in order: chainlink, uniswap, coingecko, binance, coinbase, kraken, or none
+	References           string `envconfig:"REFERENCES" default:"chainlink"`
+	ReferenceUnavailable string `envconfig:"REFERENCE_UNAVAILABLE" default:"skip"` // skip or allow unchecked writes
+
+	// Oldest accepted Chainlink answer, 0 disables the staleness check
+	ChainlinkMaxAge  time.Duration            `envconfig:"CHAINLINK_MAX_AGE" default:"3h"`
+	ChainlinkMaxAges map[string]time.Duration `envconfig:"CHAINLINK_MAX_AGES"` // Per-token max age overrides
+
+	// Fixed-point decimals of on-chain prices, 2 stores cents
+	Decimals      uint8            `envconfig:"DECIMALS" default:"2"`
+	TokenDecimals map[string]uint8 `envconfig:"TOKEN_DECIMALS"` // Per-token decimals overrides
+
+	// Gas pricing: "basefee" multiplies the base fee, "percentile" uses fee history, "fixed" uses static fees
+	GasStrategy          string        `envconfig:"GAS_STRATEGY" default:"basefee"`
+	GasTipGwei           float64       `envconfig:"GAS_TIP_GWEI" default:"1.5"`         // Tip for the fixed strategy
+	GasFeeGwei           float64       `envconfig:"GAS_FEE_GWEI" default:"30"`          // Fee cap for the fixed strategy
+	GasPercentile        float64       `envconfig:"GAS_PERCENTILE" default:"50"`        // Reward percentile for tips
+	GasHistoryBlocks     uint64        `envconfig:"GAS_HISTORY_BLOCKS" default:"10"`    // Blocks of fee history
+	GasBaseFeeMultiplier float64       `envconfig:"GAS_BASEFEE_MULTIPLIER" default:"2"` // Base fee multiplier
+	GasMaxFeeGwei        float64       `envconfig:"GAS_MAX_FEE_GWEI" default:"100"`     // Fee cap ceiling, 0 disables
+	GasStuckTimeout      time.Duration `envconfig:"GAS_STUCK_TIMEOUT" default:"2m"`     // Replace writes pending longer
+	GasBumpPercent       int           `envconfig:"GAS_BUMP_PERCENT" default:"15"`      // Fee increase per replacement
+
+	AggMethod    string        `envconfig:"AGG_METHOD" default:"median"`   // Aggregation method: median or trimmed_mean
+	AggMinQuorum int           `envconfig:"AGG_MIN_QUORUM" default:"2"`    // Agreeing sources needed, 0 is a majority
+	AggMaxSpread float64       `envconfig:"AGG_MAX_SPREAD" default:"0.05"` // Maximum relative deviation from the median
+	AggTrimRatio float64       `envconfig:"AGG_TRIM_RATIO" default:"0.2"`  // Fraction trimmed from each end
+	AggMaxAge    time.Duration `envconfig:"AGG_MAX_AGE" default:"5m"`      // Quotes older than this are ignored
 }
 
 // NewConfig creates a new Config instance from environment variables
@@ -30,5 +134,150 @@ func NewConfig() (*Config, error) {
 		return nil, fmt.Errorf("failed to process config: %w", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return &cfg, nil
 }
+
+// maxDecimals is the largest supported number of on-chain price decimals
+const maxDecimals = 36
+
+// TokenConfig is the write policy for a single token
+type TokenConfig struct {
+	Deviation  float64       // Relative change from the on-chain price needed to write
+	SanityBand float64       // Maximum relative distance from the reference price
+	Heartbeat  time.Duration // Maximum age of the on-chain price, 0 disables
+	MinPrice   float64       // Lowest accepted USD price, 0 disables
+	MaxPrice   float64       // Highest accepted USD price, 0 disables
+	Decimals   uint8         // Fixed-point decimals of the on-chain price
+	MaxAge     time.Duration // Oldest accepted Chainlink answer, 0 disables
+}
+
+// Token returns the write policy for a token, applying its overrides to the defaults
+func (c Config) Token(symbol string) TokenConfig {
+	token := TokenConfig{
+		Deviation:  c.Deviation,
+		SanityBand: c.SanityBand,
+		Heartbeat:  c.Heartbeat,
+		MinPrice:   c.MinPrices[symbol],
+		MaxPrice:   c.MaxPrices[symbol],
+		Decimals:   c.Decimals,
+		MaxAge:     c.ChainlinkMaxAge,
+	}
+
+	if v, ok := c.Deviations[symbol]; ok {
+		token.Deviation = v
+	}
+
+	if v, ok := c.SanityBands[symbol]; ok {
+		token.SanityBand = v
+	}
+
+	if v, ok := c.Heartbeats[symbol]; ok {
+		token.Heartbeat = v
+	}
+
+	if v, ok := c.TokenDecimals[symbol]; ok {
+		token.Decimals = v
+	}
+
+	if v, ok := c.ChainlinkMaxAges[symbol]; ok {
+		token.MaxAge = v
+	}
+
+	return token
+}
+
+// TokenList returns the configured tokens
+func (c Config) TokenList() []string {
+	var tokens []string
+
+	for _, token := range strings.Split(c.Tokens, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
+// Validate checks the per-token write policies
+func (c Config) Validate() error {
+	tokens := c.TokenList()
+
+	known := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		known[token] = true
+	}
+
+	// Overrides must refer to configured tokens, unless no tokens are configured yet
+	overrides := []map[string]float64{c.Deviations, c.SanityBands, c.MinPrices, c.MaxPrices}
+	for _, m := range overrides {
+		for token := range m {
+			if len(known) > 0 && !known[token] {
+				return fmt.Errorf("override for unknown token %q", token)
+			}
+		}
+	}
+
+	for _, m := range []map[string]time.Duration{c.Heartbeats, c.ChainlinkMaxAges} {
+		for token := range m {
+			if len(known) > 0 && !known[token] {
+				return fmt.Errorf("override for unknown token %q", token)
+			}
+		}
+	}
+
+	for token := range c.TokenDecimals {
+		if len(known) > 0 && !known[token] {
+			return fmt.Errorf("override for unknown token %q", token)
+		}
+	}
+
+	if err := c.Token("").validate(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+
+	for _, token := range tokens {
+		if err := c.Token(token).validate(); err != nil {
+			return fmt.Errorf("%s: %w", token, err)
+		}
+	}
+
+	return nil
+}
+
+// validate checks that a token policy is self-consistent
+func (t TokenConfig) validate() error {
+	if t.Deviation < 0 || t.Deviation >= 1 {
+		return fmt.Errorf("deviation must be in [0, 1), got %v", t.Deviation)
+	}
+
+	if t.SanityBand <= 0 || t.SanityBand >= 1 {
+		return fmt.Errorf("sanity band must be in (0, 1), got %v", t.SanityBand)
+	}
+
+	if t.Heartbeat < 0 {
+		return fmt.Errorf("heartbeat must not be negative, got %s", t.Heartbeat)
+	}
+
+	if t.MaxAge < 0 {
+		return fmt.Errorf("chainlink max age must not be negative, got %s", t.MaxAge)
+	}
+
+	if t.MinPrice < 0 || t.MaxPrice < 0 {
+		return fmt.Errorf("price limits must not be negative")
+	}
+
+	if t.Decimals > maxDecimals {
+		return fmt.Errorf("decimals must be at most %d, got %d", maxDecimals, t.Decimals)
+	}
+
+	if t.MaxPrice > 0 && t.MinPrice >= t.MaxPrice {
+		return fmt.Errorf("min price %v must be below max price %v", t.MinPrice, t.MaxPrice)
+	}
+
+	return nil
+}
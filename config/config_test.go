@@ -2,10 +2,21 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// setRequired sets the environment variables NewConfig cannot start without
+func setRequired(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("URL", "http://test.com")
+	t.Setenv("ALCHEMY", "test-alchemy")
+	t.Setenv("CONTRACT", "0x123")
+	t.Setenv("PRIVATEKEY", "test-key")
+}
+
 func TestNewConfig(t *testing.T) {
 	// Test case 1: Test with environment variables
 	t.Run("with environment variables", func(t *testing.T) {
@@ -30,7 +41,85 @@ func TestNewConfig(t *testing.T) {
 		assert.Equal(t, "test-key", cfg.PrivateKey)
 	})
 
-	// Test case 2: Test with missing environment variables
+	// Test case 2: Test aggregator defaults
+	t.Run("with aggregator defaults", func(t *testing.T) {
+		t.Setenv("TOKENS", "bitcoin")
+		t.Setenv("URL", "http://test.com")
+		t.Setenv("ALCHEMY", "test-alchemy")
+		t.Setenv("CONTRACT", "0x123")
+		t.Setenv("PRIVATEKEY", "test-key")
+
+		cfg, err := NewConfig()
+		assert.NoError(t, err)
+
+		assert.Equal(t, "median", cfg.AggMethod)
+		assert.Equal(t, 2, cfg.AggMinQuorum)
+		assert.Equal(t, 0.05, cfg.AggMaxSpread)
+		assert.Equal(t, 0.2, cfg.AggTrimRatio)
+		assert.Equal(t, 5*time.Minute, cfg.AggMaxAge)
+		assert.Equal(t, "chainlink", cfg.References)
+		assert.Equal(t, "skip", cfg.ReferenceUnavailable)
+	})
+
+	// Test case 3: Test per-token write policy overrides
+	t.Run("with token overrides", func(t *testing.T) {
+		setRequired(t)
+		t.Setenv("TOKENS", "bitcoin,tether")
+		t.Setenv("DEVIATIONS", "tether:0.001")
+		t.Setenv("SANITY_BANDS", "tether:0.05")
+		t.Setenv("HEARTBEATS", "bitcoin:10m")
+		t.Setenv("MIN_PRICES", "tether:0.9")
+		t.Setenv("MAX_PRICES", "tether:1.1")
+		t.Setenv("TOKEN_DECIMALS", "tether:8")
+		t.Setenv("CHAINLINK_MAX_AGES", "tether:24h")
+
+		cfg, err := NewConfig()
+		assert.NoError(t, err)
+
+		assert.Equal(t, TokenConfig{
+			Deviation:  0.02,
+			SanityBand: 0.2,
+			Heartbeat:  10 * time.Minute,
+			Decimals:   2,
+			MaxAge:     3 * time.Hour,
+		}, cfg.Token("bitcoin"))
+		assert.Equal(t, TokenConfig{
+			Deviation:  0.001,
+			SanityBand: 0.05,
+			Heartbeat:  time.Hour,
+			MinPrice:   0.9,
+			MaxPrice:   1.1,
+			Decimals:   8,
+			MaxAge:     24 * time.Hour,
+		}, cfg.Token("tether"))
+	})
+
+	// Test case 4: Test invalid token overrides are rejected at startup
+	t.Run("with invalid token overrides", func(t *testing.T) {
+		tests := map[string]string{
+			"DEVIATIONS":         "bitcoin:1.5",
+			"SANITY_BANDS":       "bitcoin:0",
+			"HEARTBEATS":         "dogecoin:1h",
+			"MIN_PRICES":         "bitcoin:-1",
+			"MAX_PRICES":         "ethereum:1",
+			"TOKEN_DECIMALS":     "bitcoin:40",
+			"CHAINLINK_MAX_AGES": "bitcoin:-1h",
+		}
+
+		for env, value := range tests {
+			t.Run(env, func(t *testing.T) {
+				setRequired(t)
+				t.Setenv("TOKENS", "bitcoin,ethereum")
+				t.Setenv("MIN_PRICES", "ethereum:10")
+				t.Setenv(env, value)
+
+				_, err := NewConfig()
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	// Test case 5: Test with missing environment variables
 	t.Run("with missing environment variables", func(t *testing.T) {
 		// Set empty environment variables
 		t.Setenv("PRECISION", "")
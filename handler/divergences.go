@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sljivkov/dectek/chains"
+)
+
+// DivergenceReporter reports where the on-chain price cache disagreed with contract storage
+type DivergenceReporter interface {
+	Divergences() map[string]chains.Divergence
+}
+
+// Divergences serves GET /divergences with the tokens whose cache diverged from contract storage
+// at the last reconcile, keyed by symbol. An empty object means the cache agreed.
+func Divergences(reporter DivergenceReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(reporter.Divergences()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+	}
+}
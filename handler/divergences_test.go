@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/chains"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// staticDivergences implements DivergenceReporter with fixed divergences
+type staticDivergences map[string]chains.Divergence
+
+func (d staticDivergences) Divergences() map[string]chains.Divergence {
+	return d
+}
+
+func TestDivergences(t *testing.T) {
+	detected := time.Unix(1_700_000_000, 0).UTC()
+
+	reporter := staticDivergences{
+		"bitcoin": {
+			Symbol:     "bitcoin",
+			Cached:     pricefeed.MustDecimal("30000"),
+			Stored:     pricefeed.MustDecimal("30100.5"),
+			DetectedAt: detected,
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	Divergences(reporter).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/divergences", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"bitcoin":{"symbol":"bitcoin","cached":"30000","stored":"30100.5",
+		"detectedAt":"2023-11-14T22:13:20Z"}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	Divergences(staticDivergences{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/divergences", nil))
+
+	assert.JSONEq(t, `{}`, rec.Body.String())
+}
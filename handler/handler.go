@@ -1 +1,2 @@
+// Package handler provides HTTP handlers for the DecTek service
 package handler
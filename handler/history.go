@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sljivkov/dectek/history"
+)
+
+const (
+	defaultHistoryRange    = 24 * time.Hour      // Covered when from is not given
+	defaultHistoryInterval = time.Hour           // Candle length when interval is not given
+	maxHistoryRange        = 31 * 24 * time.Hour // Largest to - from accepted, bounds the records read
+	maxHistoryCandles      = 10000               // Largest (to - from) / interval accepted
+)
+
+// HistoryResponse is the body of GET /prices/{symbol}/history
+type HistoryResponse struct {
+	Symbol   string           `json:"symbol"`
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	Interval string           `json:"interval"`
+	API      []history.Candle `json:"api"`   // Aggregated API prices
+	Chain    []history.Candle `json:"chain"` // PriceChanged events
+}
+
+// History serves GET /prices/{symbol}/history?from=&to=&interval= with OHLC candles of the API and
+// on-chain prices of a configured token. from and to take RFC 3339 times or Unix seconds and default
+// to the last 24 hours, interval takes a duration such as "5m" and defaults to one hour. Ranges longer
+// than 31 days are rejected so a request cannot read the whole database.
+// A nil store answers 503 as the price history is disabled.
+func History(store *history.Store, tokens []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			http.Error(w, "price history is disabled", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		symbol := r.PathValue("symbol")
+		if !slices.Contains(tokens, symbol) {
+			http.Error(w, fmt.Sprintf("unknown token %q", symbol), http.StatusNotFound)
+
+			return
+		}
+
+		from, to, interval, err := historyRange(r, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		apiRecords, err := store.Query(history.SourceAPI, symbol, from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		chainRecords, err := store.Query(history.SourceChain, symbol, from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		resp := HistoryResponse{
+			Symbol:   symbol,
+			From:     from,
+			To:       to,
+			Interval: interval.String(),
+			API:      history.Candles(apiRecords, interval),
+			Chain:    history.Candles(chainRecords, interval),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+	}
+}
+
+// historyRange parses and checks the from, to and interval query parameters
+func historyRange(r *http.Request, now time.Time) (time.Time, time.Time, time.Duration, error) {
+	query := r.URL.Query()
+
+	to := now.UTC()
+	if v := query.Get("to"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid to: %w", err)
+		}
+
+		to = t
+	}
+
+	from := to.Add(-defaultHistoryRange)
+	if v := query.Get("from"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid from: %w", err)
+		}
+
+		from = t
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("from %s is after to %s", from, to)
+	}
+
+	interval := defaultHistoryInterval
+	if v := query.Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid interval %q", v)
+		}
+
+		interval = d
+	}
+
+	if to.Sub(from) > maxHistoryRange {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("range of more than %s requested", maxHistoryRange)
+	}
+
+	if to.Sub(from)/interval > maxHistoryCandles {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("range of more than %d intervals requested", maxHistoryCandles)
+	}
+
+	return from, to, interval, nil
+}
+
+// parseTime accepts RFC 3339 times and Unix timestamps in seconds
+func parseTime(v string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	return time.Parse(time.RFC3339, v)
+}
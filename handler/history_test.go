@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/history"
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+func TestHistory(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	assert.NoError(t, err)
+
+	defer store.Close()
+
+	base := time.Unix(1_700_000_000, 0).Truncate(time.Hour).UTC()
+
+	err = store.Add(
+		history.Record{Source: history.SourceAPI, Symbol: "bitcoin", Price: pricefeed.MustDecimal("30000"), Time: base},
+		history.Record{Source: history.SourceAPI, Symbol: "bitcoin", Price: pricefeed.MustDecimal("30100"),
+			Time: base.Add(time.Minute)},
+		history.Record{Source: history.SourceAPI, Symbol: "bitcoin", Price: pricefeed.MustDecimal("30200"),
+			Time: base.Add(5 * time.Minute)},
+		history.Record{Source: history.SourceChain, Symbol: "bitcoin", Price: pricefeed.MustDecimal("30050"),
+			Time: base.Add(2 * time.Minute), BlockNumber: 120},
+	)
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /prices/{symbol}/history", History(store, []string{"bitcoin", "ethereum"}))
+
+	from := base.Format(time.RFC3339)
+	to := strconv.FormatInt(base.Add(10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "candles", path: "/prices/bitcoin/history?from=" + from + "&to=" + to + "&interval=5m",
+			wantStatus: http.StatusOK},
+		{name: "no records", path: "/prices/ethereum/history?from=" + from + "&to=" + to, wantStatus: http.StatusOK},
+		{name: "unknown token", path: "/prices/dogecoin/history", wantStatus: http.StatusNotFound},
+		{name: "invalid from", path: "/prices/bitcoin/history?from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "invalid interval", path: "/prices/bitcoin/history?interval=-5m", wantStatus: http.StatusBadRequest},
+		{name: "from after to", path: "/prices/bitcoin/history?from=" + to + "&to=" + from,
+			wantStatus: http.StatusBadRequest},
+		{name: "range too long", path: "/prices/bitcoin/history?from=0&interval=8760h", wantStatus: http.StatusBadRequest},
+		{name: "too many candles", path: "/prices/bitcoin/history?interval=1s", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tests[0].path, nil))
+
+	var resp HistoryResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+	assert.Equal(t, "bitcoin", resp.Symbol)
+	assert.Equal(t, "5m0s", resp.Interval)
+	assert.True(t, resp.From.Equal(base))
+	assert.True(t, resp.To.Equal(base.Add(10*time.Minute)))
+
+	assert.Len(t, resp.API, 2)
+	assert.Equal(t, "30000", resp.API[0].Open.String())
+	assert.Equal(t, "30100", resp.API[0].High.String())
+	assert.Equal(t, "30100", resp.API[0].Close.String())
+	assert.Equal(t, 2, resp.API[0].Count)
+	assert.Equal(t, "30200", resp.API[1].Open.String())
+
+	assert.Len(t, resp.Chain, 1)
+	assert.Equal(t, "30050", resp.Chain[0].Close.String())
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	rec := httptest.NewRecorder()
+	History(nil, []string{"bitcoin"}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/prices/bitcoin/history", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,357 @@
+// Package history keeps a durable record of API and on-chain prices for incident reviews
+package history
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// Source tells where a recorded price was observed
+type Source string
+
+const (
+	SourceAPI   Source = "api"   // Aggregated API price tick
+	SourceChain Source = "chain" // PriceChanged event of the feed contract
+)
+
+// openTimeout bounds how long Open waits for another process holding the database
+const openTimeout = 5 * time.Second
+
+// Range of times that keys can hold, records outside it are stored at the nearest bound
+var (
+	minKeyTime = time.Unix(0, 0)
+	maxKeyTime = time.Unix(0, math.MaxInt64)
+)
+
+// Record is a single observed price
+type Record struct {
+	Source      Source            `json:"source"`
+	Symbol      string            `json:"symbol"`
+	Price       pricefeed.Decimal `json:"price"`
+	Time        time.Time         `json:"time"`                  // Tick time, or the event timestamp on-chain
+	Providers   []string          `json:"providers,omitempty"`   // API sources aggregated into the price
+	BlockNumber uint64            `json:"blockNumber,omitempty"` // On-chain only
+	TxHash      string            `json:"txHash,omitempty"`      // On-chain only
+	LogIndex    uint              `json:"logIndex,omitempty"`    // On-chain only
+}
+
+// Retention is how long records of each source are kept, a missing or 0 entry keeps them forever
+type Retention map[Source]time.Duration
+
+// Store is an embedded bbolt database of price records. Records live in one bucket per source and
+// symbol, keyed by time so ranges are read in order. On-chain records are keyed by their log, so
+// replaying an event does not record it twice.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the history database at path
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open price history %s: %w", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add stores records in a single transaction
+func (s *Store) Add(records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, r := range records {
+			bucket, err := symbolBucket(tx, r.Source, r.Symbol)
+			if err != nil {
+				return err
+			}
+
+			value, err := json.Marshal(r)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s record: %w", r.Symbol, err)
+			}
+
+			key, err := recordKey(bucket, r)
+			if err != nil {
+				return err
+			}
+
+			if err := bucket.Put(key, value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store price history: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes on-chain records, identified by their time, block number and log index.
+// It is used when a reorg removes an event that was already recorded.
+func (s *Store) Remove(records ...Record) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, r := range records {
+			if r.Source != SourceChain {
+				return fmt.Errorf("only %s records can be removed, got %s", SourceChain, r.Source)
+			}
+
+			root := tx.Bucket([]byte(r.Source))
+			if root == nil {
+				continue
+			}
+
+			bucket := root.Bucket([]byte(r.Symbol))
+			if bucket == nil {
+				continue
+			}
+
+			key, err := recordKey(bucket, r)
+			if err != nil {
+				return err
+			}
+
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove price history: %w", err)
+	}
+
+	return nil
+}
+
+// Query returns the records of a source and symbol with from <= Time <= to, oldest first
+func (s *Store) Query(source Source, symbol string, from, to time.Time) ([]Record, error) {
+	var records []Record
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket([]byte(source))
+		if root == nil {
+			return nil
+		}
+
+		bucket := root.Bucket([]byte(symbol))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+
+		for k, v := c.Seek(timeKey(from)); k != nil && keyTime(k) <= unixNano(to); k, v = c.Next() {
+			var r Record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("failed to decode %s record: %w", symbol, err)
+			}
+
+			records = append(records, r)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price history: %w", err)
+	}
+
+	return records, nil
+}
+
+// Prune deletes the records of a source older than before and returns how many were deleted
+func (s *Store) Prune(source Source, before time.Time) (int, error) {
+	deleted := 0
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket([]byte(source))
+		if root == nil {
+			return nil
+		}
+
+		return root.ForEachBucket(func(name []byte) error {
+			c := root.Bucket(name).Cursor()
+
+			// Deleting moves the cursor to the next key
+			for k, _ := c.First(); k != nil && keyTime(k) < unixNano(before); k, _ = c.First() {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+
+				deleted++
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return deleted, fmt.Errorf("failed to prune %s price history: %w", source, err)
+	}
+
+	return deleted, nil
+}
+
+// Enforce prunes every source with a retention period, relative to now
+func (s *Store) Enforce(retention Retention, now time.Time) error {
+	for source, keep := range retention {
+		if keep <= 0 {
+			continue
+		}
+
+		deleted, err := s.Prune(source, now.Add(-keep))
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			log.Printf("🧹 Pruned %d %s price records older than %s", deleted, source, keep)
+		}
+	}
+
+	return nil
+}
+
+// RunRetention enforces retention immediately and then every interval until ctx is canceled
+func (s *Store) RunRetention(ctx context.Context, retention Retention, interval time.Duration) {
+	if err := s.Enforce(retention, time.Now()); err != nil {
+		log.Printf("❌ Price history retention failed: %v", err)
+	}
+
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Enforce(retention, time.Now()); err != nil {
+				log.Printf("❌ Price history retention failed: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// symbolBucket returns the bucket of a source and symbol, creating it if needed
+func symbolBucket(tx *bolt.Tx, source Source, symbol string) (*bolt.Bucket, error) {
+	root, err := tx.CreateBucketIfNotExists([]byte(source))
+	if err != nil {
+		return nil, err
+	}
+
+	return root.CreateBucketIfNotExists([]byte(symbol))
+}
+
+// recordKey orders records by time. On-chain records are made unique by block and log index,
+// API ticks by a per-bucket sequence.
+func recordKey(bucket *bolt.Bucket, r Record) ([]byte, error) {
+	key := timeKey(r.Time)
+
+	if r.Source == SourceChain {
+		key = binary.BigEndian.AppendUint64(key, r.BlockNumber)
+
+		return binary.BigEndian.AppendUint64(key, uint64(r.LogIndex)), nil
+	}
+
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return nil, err
+	}
+
+	return binary.BigEndian.AppendUint64(key, seq), nil
+}
+
+// timeKey encodes t as a big-endian key prefix
+func timeKey(t time.Time) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(unixNano(t)))
+}
+
+// unixNano returns t in Unix nanoseconds, clamped to the times keys can hold
+func unixNano(t time.Time) int64 {
+	switch {
+	case t.Before(minKeyTime):
+		return 0
+	case t.After(maxKeyTime):
+		return math.MaxInt64
+	default:
+		return t.UnixNano()
+	}
+}
+
+// keyTime decodes the time prefix of a key in Unix nanoseconds
+func keyTime(key []byte) int64 {
+	return int64(binary.BigEndian.Uint64(key[:8]))
+}
+
+// Candle summarizes the records of one interval
+type Candle struct {
+	Start time.Time         `json:"start"`
+	Open  pricefeed.Decimal `json:"open"`
+	High  pricefeed.Decimal `json:"high"`
+	Low   pricefeed.Decimal `json:"low"`
+	Close pricefeed.Decimal `json:"close"`
+	Count int               `json:"count"` // Records in the interval
+}
+
+// Candles groups records, oldest first, into candles of the given interval. Candles start at multiples
+// of interval since the Unix epoch, and intervals without records are left out.
+func Candles(records []Record, interval time.Duration) []Candle {
+	candles := []Candle{}
+
+	for _, r := range records {
+		ns := unixNano(r.Time)
+		start := time.Unix(0, ns-ns%int64(interval)).UTC()
+
+		if n := len(candles); n > 0 && candles[n-1].Start.Equal(start) {
+			c := &candles[n-1]
+
+			if r.Price.Cmp(c.High) > 0 {
+				c.High = r.Price
+			}
+
+			if r.Price.Cmp(c.Low) < 0 {
+				c.Low = r.Price
+			}
+
+			c.Close = r.Price
+			c.Count++
+
+			continue
+		}
+
+		candles = append(candles, Candle{
+			Start: start,
+			Open:  r.Price,
+			High:  r.Price,
+			Low:   r.Price,
+			Close: r.Price,
+			Count: 1,
+		})
+	}
+
+	return candles
+}
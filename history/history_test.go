@@ -0,0 +1,168 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sljivkov/dectek/pricefeed"
+)
+
+// openTestStore opens a store in a temporary directory, closed when the test ends
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	assert.NoError(t, err)
+
+	t.Cleanup(func() { assert.NoError(t, store.Close()) })
+
+	return store
+}
+
+func TestStoreQuery(t *testing.T) {
+	store := openTestStore(t)
+	base := time.Unix(1_700_000_000, 0).UTC()
+
+	err := store.Add(
+		Record{Source: SourceAPI, Symbol: "bitcoin", Price: pricefeed.MustDecimal("30000.12"), Time: base.Add(time.Minute),
+			Providers: []string{"coingecko", "binance"}},
+		Record{Source: SourceAPI, Symbol: "bitcoin", Price: pricefeed.MustDecimal("30000"), Time: base},
+		// Two ticks at the same time are both kept
+		Record{Source: SourceAPI, Symbol: "bitcoin", Price: pricefeed.MustDecimal("30001"), Time: base},
+		Record{Source: SourceAPI, Symbol: "ethereum", Price: pricefeed.MustDecimal("2000"), Time: base},
+		Record{Source: SourceChain, Symbol: "bitcoin", Price: pricefeed.MustDecimal("29999"), Time: base,
+			BlockNumber: 120, TxHash: "0x01"},
+	)
+	assert.NoError(t, err)
+
+	records, err := store.Query(SourceAPI, "bitcoin", base, base.Add(time.Hour))
+	assert.NoError(t, err)
+
+	var prices []string
+	for _, r := range records {
+		prices = append(prices, r.Price.String())
+	}
+
+	assert.Equal(t, []string{"30000", "30001", "30000.12"}, prices)
+	assert.Equal(t, []string{"coingecko", "binance"}, records[2].Providers)
+	assert.True(t, records[2].Time.Equal(base.Add(time.Minute)))
+
+	// Both bounds are inclusive
+	records, err = store.Query(SourceAPI, "bitcoin", base.Add(time.Second), base.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	records, err = store.Query(SourceChain, "bitcoin", time.Time{}, base.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, uint64(120), records[0].BlockNumber)
+
+	records, err = store.Query(SourceChain, "ethereum", time.Time{}, base.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestStoreChainRecordsAreIdempotent(t *testing.T) {
+	store := openTestStore(t)
+	at := time.Unix(1_700_000_000, 0)
+
+	event := Record{Source: SourceChain, Symbol: "bitcoin", Price: pricefeed.MustDecimal("30000"), Time: at,
+		BlockNumber: 120, TxHash: "0x01", LogIndex: 3}
+
+	// A replayed event overwrites its earlier record
+	assert.NoError(t, store.Add(event))
+	assert.NoError(t, store.Add(event))
+
+	other := event
+	other.LogIndex = 4
+	assert.NoError(t, store.Add(other))
+
+	records, err := store.Query(SourceChain, "bitcoin", at, at)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	// A removed event is deleted by its key alone
+	removed := Record{Source: SourceChain, Symbol: "bitcoin", Time: at, BlockNumber: 120, LogIndex: 3}
+	assert.NoError(t, store.Remove(removed))
+
+	records, err = store.Query(SourceChain, "bitcoin", at, at)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, uint(4), records[0].LogIndex)
+
+	assert.Error(t, store.Remove(Record{Source: SourceAPI, Symbol: "bitcoin", Time: at}))
+}
+
+func TestStoreRetention(t *testing.T) {
+	store := openTestStore(t)
+	now := time.Unix(1_700_000_000, 0)
+
+	var records []Record
+
+	for i := range 5 {
+		at := now.Add(-time.Duration(i) * 24 * time.Hour)
+		records = append(records,
+			Record{Source: SourceAPI, Symbol: "bitcoin", Price: pricefeed.DecimalFromInt(int64(i)), Time: at},
+			Record{Source: SourceAPI, Symbol: "ethereum", Price: pricefeed.DecimalFromInt(int64(i)), Time: at},
+			Record{Source: SourceChain, Symbol: "bitcoin", Price: pricefeed.DecimalFromInt(int64(i)), Time: at,
+				BlockNumber: uint64(100 - i)},
+		)
+	}
+
+	assert.NoError(t, store.Add(records...))
+
+	// API ticks are kept for two days, on-chain events forever
+	err := store.Enforce(Retention{SourceAPI: 48 * time.Hour, SourceChain: 0}, now)
+	assert.NoError(t, err)
+
+	for _, symbol := range []string{"bitcoin", "ethereum"} {
+		kept, err := store.Query(SourceAPI, symbol, time.Time{}, now)
+		assert.NoError(t, err)
+		assert.Len(t, kept, 3)
+		assert.Equal(t, "2", kept[0].Price.String())
+	}
+
+	kept, err := store.Query(SourceChain, "bitcoin", time.Time{}, now)
+	assert.NoError(t, err)
+	assert.Len(t, kept, 5)
+
+	deleted, err := store.Prune(SourceChain, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, deleted)
+}
+
+func TestCandles(t *testing.T) {
+	base := time.Unix(1_700_000_000, 0).Truncate(time.Hour)
+
+	tick := func(offset time.Duration, price string) Record {
+		return Record{Source: SourceAPI, Symbol: "bitcoin", Price: pricefeed.MustDecimal(price), Time: base.Add(offset)}
+	}
+
+	candles := Candles([]Record{
+		tick(0, "100"),
+		tick(10*time.Minute, "105.5"),
+		tick(20*time.Minute, "98"),
+		tick(59*time.Minute, "101"),
+		// The next hour has no records
+		tick(2*time.Hour+time.Minute, "110"),
+	}, time.Hour)
+
+	assert.Len(t, candles, 2)
+
+	assert.True(t, candles[0].Start.Equal(base))
+	assert.Equal(t, "100", candles[0].Open.String())
+	assert.Equal(t, "105.5", candles[0].High.String())
+	assert.Equal(t, "98", candles[0].Low.String())
+	assert.Equal(t, "101", candles[0].Close.String())
+	assert.Equal(t, 4, candles[0].Count)
+
+	assert.True(t, candles[1].Start.Equal(base.Add(2*time.Hour)))
+	assert.Equal(t, "110", candles[1].Open.String())
+	assert.Equal(t, "110", candles[1].Close.String())
+	assert.Equal(t, 1, candles[1].Count)
+
+	assert.Empty(t, Candles(nil, time.Hour))
+}
@@ -4,89 +4,294 @@ package main
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
 
 	"github.com/sljivkov/dectek/apis"
 	"github.com/sljivkov/dectek/chains"
 	"github.com/sljivkov/dectek/config"
+	"github.com/sljivkov/dectek/handler"
+	"github.com/sljivkov/dectek/history"
 	"github.com/sljivkov/dectek/pricefeed"
 )
 
+// Process exit codes
+const (
+	exitOK      = 0 // Shut down cleanly after a signal
+	exitFailure = 1 // A pipeline stage failed or did not drain in time
+)
+
 // Global state variables for price management
 var (
-	apiPrices = make(map[string]float64)
+	apiPrices = make(map[string]pricefeed.Decimal)
 	mu        sync.RWMutex
 	readyCh   = make(chan struct{}) // Signals when initial prices are loaded
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run starts the price pipeline and blocks until it has shut down, returning the process exit code
+func run() int {
 	cfg, err := config.NewConfig()
 	if err != nil {
 		log.Fatalf("❌ Failed to initialize config: %v", err)
 	}
 
-	sepoliaFeed, err := chains.NewSepoliaPriceFeed(cfg.PrivateKey, cfg.Alchemy, cfg.Contract)
+	client, err := ethclient.Dial(cfg.Alchemy)
 	if err != nil {
-		log.Fatalf("❌ Failed to initialize Sepolia feed: %v", err)
-
-		return
+		log.Fatalf("❌ Failed to connect to Ethereum node: %v", err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// The root context is canceled on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	geckoFeed := apis.NewCoinGecko(*cfg)
 
-	allFeed := NewAllFeed(geckoFeed, sepoliaFeed)
+	var binanceFeed pricefeed.PriceProvider = apis.NewBinance(*cfg)
+	if cfg.BinanceMode == "stream" {
+		binanceFeed = apis.NewBinanceStream(*cfg)
+	}
+
+	coinbaseFeed := apis.NewCoinbase(*cfg)
+	krakenFeed := apis.NewKraken(*cfg)
+
+	sources := []pricefeed.Source{
+		{Name: "coingecko", Provider: geckoFeed},
+		{Name: "binance", Provider: binanceFeed},
+		{Name: "coinbase", Provider: coinbaseFeed},
+		{Name: "kraken", Provider: krakenFeed},
+	}
+
+	var twapFeed *chains.UniswapTWAP
+
+	if cfg.UniswapPools != "" {
+		pools, err := chains.ParseUniswapPools(cfg.UniswapPools)
+		if err != nil {
+			log.Fatalf("❌ Failed to parse Uniswap pools: %v", err)
+		}
+
+		twapFeed, err = chains.NewUniswapTWAP(client, pools, cfg.UniswapWindow, cfg.UniswapInterval)
+		if err != nil {
+			log.Fatalf("❌ Failed to initialize Uniswap TWAP feed: %v", err)
+		}
+
+		sources = append(sources, pricefeed.Source{Name: "uniswap", Provider: twapFeed})
+	}
+
+	reference, err := newReference(ctx, *cfg, client, twapFeed)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize reference prices: %v", err)
+	}
+
+	var priceHistory *history.Store
+
+	if cfg.HistoryFile != "" {
+		priceHistory, err = history.Open(cfg.HistoryFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to open price history: %v", err)
+		}
+
+		defer priceHistory.Close()
+
+		go priceHistory.RunRetention(ctx, history.Retention{
+			history.SourceAPI:   cfg.HistoryAPIRetention,
+			history.SourceChain: cfg.HistoryChainRetention,
+		}, cfg.HistoryPruneInterval)
+	}
+
+	sepoliaFeed, err := chains.NewSepoliaPriceFeed(*cfg, client, reference, priceHistory)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize Sepolia feed: %v", err)
+	}
+
+	aggregator, err := pricefeed.NewAggregator(pricefeed.AggregatorConfig{
+		Method:    pricefeed.AggregationMethod(cfg.AggMethod),
+		MinQuorum: cfg.AggMinQuorum,
+		MaxSpread: cfg.AggMaxSpread,
+		TrimRatio: cfg.AggTrimRatio,
+		MaxAge:    cfg.AggMaxAge,
+	}, sources...)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize price aggregator: %v", err)
+	}
+
+	allFeed := NewAllFeed(aggregator, sepoliaFeed)
 
 	// Initialize channels for price data flow
 	var (
 		out     = make(chan pricefeed.Price)
 		priceCh = make(chan []pricefeed.Price)
+		chainCh = make(chan []pricefeed.Price)
+		wg      sync.WaitGroup
 	)
 
-	// Start on-chain price listener
-	go allFeed.ListenOnChainPriceUpdate(ctx, out)
+	// Process on-chain price updates until the listener closes out
+	wg.Add(1)
 
-	// Process on-chain price updates
 	go func() {
+		defer wg.Done()
+
 		for data := range out {
-			log.Printf("📥 Received on-chain price update: %s = %.2f", data.Symbol, data.USD)
+			log.Printf("📥 Received on-chain price update: %s = %s", data.Symbol, data.USD)
 		}
 	}()
 
+	// Seed the on-chain price cache before any write is validated
+	if err := sepoliaFeed.Backfill(ctx, out); err != nil {
+		log.Fatalf("❌ Failed to backfill on-chain prices: %v", err)
+	}
+
+	if err := sepoliaFeed.Reconcile(ctx); err != nil {
+		log.Printf("⚠️ Failed to seed on-chain prices from contract storage: %v", err)
+	}
+
+	go sepoliaFeed.RunReconciler(ctx)
+
+	// Start on-chain price listener
+	go allFeed.ListenOnChainPriceUpdate(ctx, out)
+
 	// Start API price updater
-	go allFeed.UpdatePriceFromApi(priceCh)
+	go allFeed.UpdatePriceFromApi(ctx, priceCh)
 
-	// Start chain price writer
-	go allFeed.WritePricesToChain(ctx, priceCh)
+	// Start chain price writer, it drains pending transactions before returning
+	wg.Add(1)
 
-	// Start HTTP server
 	go func() {
-		log.Println("🚀 Starting server on :8080")
+		defer wg.Done()
 
-		if err := http.ListenAndServe(":8080", nil); err != nil {
-			log.Fatalf("❌ Server failed: %v", err)
-		}
+		allFeed.WritePricesToChain(ctx, chainCh)
 	}()
 
 	// Register HTTP handlers
-	http.HandleFunc("/prices", pricesHandler)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/prices", pricesHandler)
+	mux.HandleFunc("GET /prices/{symbol}/history", handler.History(priceHistory, cfg.TokenList()))
+	mux.HandleFunc("GET /divergences", handler.Divergences(sepoliaFeed))
+
+	server := &http.Server{
+		Addr:              ":8080",
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serverErr := make(chan error, 1)
 
-	// Main price update loop
+	// Start HTTP server, a failure shuts down the whole pipeline
+	go func() {
+		log.Println("🚀 Starting server on :8080")
+
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+
+			stop()
+		}
+	}()
+
+	// Main price update loop, runs until the API feed closes priceCh
 	log.Println("✨ Starting main price update loop")
 
 	for data := range priceCh {
 		mu.Lock()
 
 		for _, coin := range data {
-			apiPrices[coin.Symbol] = coin.USD
+			// Report prices at the precision they are written on-chain
+			apiPrices[coin.Symbol] = coin.USD.Round(cfg.Token(coin.Symbol).Decimals)
 
-			log.Printf("💰 Updated %s price: %.2f", coin.Symbol, coin.USD)
+			log.Printf("💰 Updated %s price: %s (sources: %s)", coin.Symbol, coin.USD, strings.Join(coin.Sources, ","))
 		}
 
 		mu.Unlock()
+
+		if priceHistory != nil {
+			recordAPIPrices(priceHistory, data, time.Now())
+		}
+
+		// Forward to the chain writer
+		select {
+		case chainCh <- data:
+		case <-ctx.Done():
+		}
+	}
+
+	close(chainCh)
+
+	log.Println("🛑 API price feed closed, shutting down")
+
+	exitCode := exitOK
+
+	select {
+	case err := <-serverErr:
+		log.Printf("❌ Server failed: %v", err)
+
+		exitCode = exitFailure
+	default:
+		if ctx.Err() == nil {
+			log.Println("❌ API price feed stopped unexpectedly")
+
+			exitCode = exitFailure
+		}
+	}
+
+	return shutdown(server, &wg, cfg.ShutdownTimeout, exitCode)
+}
+
+// shutdown stops the HTTP server and waits for the remaining pipeline stages, bounded by timeout
+func shutdown(server *http.Server, wg *sync.WaitGroup, timeout time.Duration, exitCode int) int {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("❌ HTTP server shutdown failed: %v", err)
+
+		exitCode = exitFailure
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("👋 Shutdown complete")
+	case <-shutdownCtx.Done():
+		log.Println("❌ Timed out waiting for pipeline to drain")
+
+		exitCode = exitFailure
+	}
+
+	return exitCode
+}
+
+// recordAPIPrices stores an aggregated API tick in the price history, failures are only logged
+func recordAPIPrices(store *history.Store, data []pricefeed.Price, at time.Time) {
+	records := make([]history.Record, 0, len(data))
+
+	for _, coin := range data {
+		records = append(records, history.Record{
+			Source:    history.SourceAPI,
+			Symbol:    coin.Symbol,
+			Price:     coin.USD,
+			Time:      at,
+			Providers: coin.Sources,
+		})
+	}
+
+	if err := store.Add(records...); err != nil {
+		log.Printf("⚠️ Failed to record API prices: %v", err)
 	}
 }
@@ -0,0 +1,269 @@
+package pricefeed
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AggregationMethod selects how accepted quotes are combined into a single price
+type AggregationMethod string
+
+const (
+	// MethodMedian publishes the median of the accepted quotes
+	MethodMedian AggregationMethod = "median"
+	// MethodTrimmedMean publishes the mean after trimming TrimRatio from each end
+	MethodTrimmedMean AggregationMethod = "trimmed_mean"
+)
+
+// AggregatorConfig controls quorum and outlier rejection for an Aggregator
+type AggregatorConfig struct {
+	Method    AggregationMethod // How accepted quotes are combined
+	MinQuorum int               // Minimum number of agreeing sources needed to emit a price, 0 means a majority
+	MaxSpread float64           // Maximum relative distance from the median (0.05 = 5%), 0 disables
+	TrimRatio float64           // Fraction trimmed from each end for MethodTrimmedMean
+	MaxAge    time.Duration     // Quotes older than this are ignored, 0 disables
+}
+
+// Source is a named price provider feeding an Aggregator
+type Source struct {
+	Name     string
+	Provider PriceProvider
+}
+
+// quote is the latest price reported by a single source
+type quote struct {
+	usd Decimal
+	at  time.Time
+}
+
+// sourceUpdate is a batch of prices received from a single source
+type sourceUpdate struct {
+	source string
+	prices []Price
+}
+
+// Aggregator implements PriceProvider by combining several underlying providers
+type Aggregator struct {
+	cfg     AggregatorConfig
+	sources []Source
+	mu      sync.Mutex
+	quotes  map[string]map[string]quote // symbol -> source -> latest quote
+	now     func() time.Time
+}
+
+// NewAggregator creates a new Aggregator over the given sources. Without a MinQuorum a price
+// needs a majority of the sources, so no single source decides it on its own.
+func NewAggregator(cfg AggregatorConfig, sources ...Source) (*Aggregator, error) {
+	switch cfg.Method {
+	case "":
+		cfg.Method = MethodMedian
+	case MethodMedian, MethodTrimmedMean:
+	default:
+		return nil, fmt.Errorf("unknown aggregation method %q", cfg.Method)
+	}
+
+	if cfg.MinQuorum < 1 {
+		cfg.MinQuorum = len(sources)/2 + 1
+	}
+
+	return &Aggregator{
+		cfg:     cfg,
+		sources: sources,
+		quotes:  make(map[string]map[string]quote),
+		now:     time.Now,
+	}, nil
+}
+
+// UpdatePriceFromApi starts every underlying provider and sends aggregated prices
+// to the provided channel whenever one of them reports a new batch. Once ctx is
+// canceled it waits for every provider to stop and then closes priceCh.
+func (a *Aggregator) UpdatePriceFromApi(ctx context.Context, priceCh chan<- []Price) {
+	log.Printf("📡 Starting price aggregator over %d sources", len(a.sources))
+
+	defer close(priceCh)
+
+	var (
+		updates = make(chan sourceUpdate)
+		wg      sync.WaitGroup
+	)
+
+	for _, src := range a.sources {
+		ch := make(chan []Price)
+
+		go src.Provider.UpdatePriceFromApi(ctx, ch)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Drain until the provider closes its channel
+			for prices := range ch {
+				select {
+				case updates <- sourceUpdate{source: src.Name, prices: prices}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(updates)
+	}()
+
+	for update := range updates {
+		prices := a.ingest(update.source, update.prices)
+		if len(prices) == 0 {
+			continue
+		}
+
+		select {
+		case priceCh <- prices:
+		case <-ctx.Done():
+		}
+	}
+
+	log.Println("🛑 Price aggregator stopped")
+}
+
+// ingest records a batch from one source and returns the aggregated prices
+// for every symbol in that batch that satisfies quorum
+func (a *Aggregator) ingest(source string, prices []Price) []Price {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+
+	for _, p := range prices {
+		if a.quotes[p.Symbol] == nil {
+			a.quotes[p.Symbol] = make(map[string]quote)
+		}
+
+		a.quotes[p.Symbol][source] = quote{usd: p.USD, at: now}
+	}
+
+	out := make([]Price, 0, len(prices))
+
+	for _, p := range prices {
+		agg, err := a.aggregate(p.Symbol, now)
+		if err != nil {
+			log.Printf("⚖️ Skipping %s: %v", p.Symbol, err)
+
+			continue
+		}
+
+		out = append(out, agg)
+	}
+
+	return out
+}
+
+// aggregate combines the fresh quotes for a symbol, rejecting outliers
+func (a *Aggregator) aggregate(symbol string, now time.Time) (Price, error) {
+	names := make([]string, 0, len(a.quotes[symbol]))
+
+	for name, q := range a.quotes[symbol] {
+		if a.cfg.MaxAge > 0 && now.Sub(q.at) > a.cfg.MaxAge {
+			continue
+		}
+
+		if q.usd.Sign() <= 0 {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	if len(names) < a.cfg.MinQuorum {
+		return Price{}, fmt.Errorf("only %d fresh sources, quorum is %d", len(names), a.cfg.MinQuorum)
+	}
+
+	values := make([]Decimal, len(names))
+	for i, name := range names {
+		values[i] = a.quotes[symbol][name].usd
+	}
+
+	mid := median(values)
+
+	accepted := make([]string, 0, len(names))
+	acceptedValues := make([]Decimal, 0, len(names))
+
+	for i, name := range names {
+		if a.cfg.MaxSpread > 0 && values[i].Sub(mid).Abs().Div(mid).Float64() > a.cfg.MaxSpread {
+			log.Printf("⚠️ Rejecting %s quote from %s: %s deviates from median %s", symbol, name, values[i], mid)
+
+			continue
+		}
+
+		accepted = append(accepted, name)
+		acceptedValues = append(acceptedValues, values[i])
+	}
+
+	if len(accepted) < a.cfg.MinQuorum {
+		return Price{}, fmt.Errorf("only %d of %d sources agree, quorum is %d",
+			len(accepted), len(names), a.cfg.MinQuorum)
+	}
+
+	var usd Decimal
+
+	switch a.cfg.Method {
+	case MethodMedian:
+		usd = median(acceptedValues)
+	case MethodTrimmedMean:
+		usd = trimmedMean(acceptedValues, a.cfg.TrimRatio)
+	default:
+		return Price{}, fmt.Errorf("unknown aggregation method %q", a.cfg.Method)
+	}
+
+	sort.Strings(accepted)
+
+	return Price{
+		Symbol:  symbol,
+		USD:     usd,
+		Sources: accepted,
+	}, nil
+}
+
+// sortDecimals returns a sorted copy of values
+func sortDecimals(values []Decimal) []Decimal {
+	sorted := append([]Decimal(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+
+	return sorted
+}
+
+// median returns the median of values without modifying the input
+func median(values []Decimal) Decimal {
+	sorted := sortDecimals(values)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+
+	return sorted[n/2-1].Add(sorted[n/2]).Div(DecimalFromInt(2))
+}
+
+// trimmedMean returns the mean after discarding ratio of the values from each end
+func trimmedMean(values []Decimal, ratio float64) Decimal {
+	sorted := sortDecimals(values)
+
+	trim := int(float64(len(sorted)) * ratio)
+	if 2*trim >= len(sorted) {
+		return median(sorted)
+	}
+
+	sorted = sorted[trim : len(sorted)-trim]
+
+	var sum Decimal
+	for _, v := range sorted {
+		sum = sum.Add(v)
+	}
+
+	return sum.Div(DecimalFromInt(int64(len(sorted))))
+}
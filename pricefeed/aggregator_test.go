@@ -0,0 +1,164 @@
+package pricefeed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// staticProvider sends a single fixed batch of prices and waits for cancellation
+type staticProvider struct {
+	prices []Price
+}
+
+func (s *staticProvider) UpdatePriceFromApi(ctx context.Context, priceCh chan<- []Price) {
+	defer close(priceCh)
+
+	select {
+	case priceCh <- s.prices:
+	case <-ctx.Done():
+		return
+	}
+
+	<-ctx.Done()
+}
+
+// newTestAggregator creates an Aggregator from a valid config
+func newTestAggregator(t *testing.T, cfg AggregatorConfig, sources ...Source) *Aggregator {
+	t.Helper()
+
+	agg, err := NewAggregator(cfg, sources...)
+	assert.NoError(t, err)
+
+	return agg
+}
+
+func TestNewAggregator(t *testing.T) {
+	agg := newTestAggregator(t, AggregatorConfig{})
+	assert.Equal(t, MethodMedian, agg.cfg.Method)
+	assert.Equal(t, 1, agg.cfg.MinQuorum)
+
+	// The default quorum is a majority of the sources
+	sources := make([]Source, 4)
+	assert.Equal(t, 3, newTestAggregator(t, AggregatorConfig{}, sources...).cfg.MinQuorum)
+	assert.Equal(t, 2, newTestAggregator(t, AggregatorConfig{}, sources[:3]...).cfg.MinQuorum)
+	assert.Equal(t, 2, newTestAggregator(t, AggregatorConfig{MinQuorum: 2}, sources...).cfg.MinQuorum)
+
+	// An unknown method fails up front instead of on every tick
+	_, err := NewAggregator(AggregatorConfig{Method: "mean"})
+	assert.ErrorContains(t, err, "unknown aggregation method")
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         AggregatorConfig
+		quotes      map[string]float64
+		wantUSD     string
+		wantSources []string
+		wantErr     bool
+	}{
+		{
+			name:        "median of three sources",
+			cfg:         AggregatorConfig{MinQuorum: 2},
+			quotes:      map[string]float64{"a": 100, "b": 102, "c": 101},
+			wantUSD:     "101",
+			wantSources: []string{"a", "b", "c"},
+		},
+		{
+			name:        "outlier rejected by max spread",
+			cfg:         AggregatorConfig{MinQuorum: 2, MaxSpread: 0.05},
+			quotes:      map[string]float64{"a": 100, "b": 102, "c": 150},
+			wantUSD:     "101",
+			wantSources: []string{"a", "b"},
+		},
+		{
+			name:    "quorum not met",
+			cfg:     AggregatorConfig{MinQuorum: 3},
+			quotes:  map[string]float64{"a": 100, "b": 102},
+			wantErr: true,
+		},
+		{
+			name:    "quorum not met after rejection",
+			cfg:     AggregatorConfig{MinQuorum: 2, MaxSpread: 0.01},
+			quotes:  map[string]float64{"a": 100, "b": 120},
+			wantErr: true,
+		},
+		{
+			name:        "trimmed mean",
+			cfg:         AggregatorConfig{Method: MethodTrimmedMean, TrimRatio: 0.25},
+			quotes:      map[string]float64{"a": 1, "b": 100, "c": 102, "d": 1000},
+			wantUSD:     "101",
+			wantSources: []string{"a", "b", "c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := newTestAggregator(t, tt.cfg)
+
+			for source, usd := range tt.quotes {
+				agg.ingest(source, []Price{{Symbol: "bitcoin", USD: DecimalFromFloat(usd)}})
+			}
+
+			got, err := agg.aggregate("bitcoin", agg.now())
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantUSD, got.USD.String())
+			assert.Equal(t, tt.wantSources, got.Sources)
+		})
+	}
+}
+
+func TestAggregateIgnoresStaleQuotes(t *testing.T) {
+	agg := newTestAggregator(t, AggregatorConfig{MinQuorum: 2, MaxAge: time.Minute})
+
+	start := time.Now()
+	agg.now = func() time.Time { return start }
+	agg.ingest("a", []Price{{Symbol: "bitcoin", USD: DecimalFromInt(100)}})
+
+	agg.now = func() time.Time { return start.Add(2 * time.Minute) }
+	prices := agg.ingest("b", []Price{{Symbol: "bitcoin", USD: DecimalFromInt(101)}})
+
+	assert.Empty(t, prices)
+}
+
+func TestAggregatorUpdatePriceFromApi(t *testing.T) {
+	agg := newTestAggregator(t, AggregatorConfig{},
+		Source{Name: "first", Provider: &staticProvider{prices: []Price{{Symbol: "bitcoin", USD: DecimalFromInt(30000)}}}},
+	)
+
+	priceCh := make(chan []Price, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go agg.UpdatePriceFromApi(ctx, priceCh)
+
+	select {
+	case prices := <-priceCh:
+		assert.Len(t, prices, 1)
+		assert.Equal(t, "bitcoin", prices[0].Symbol)
+		assert.Equal(t, "30000", prices[0].USD.String())
+		assert.Equal(t, []string{"first"}, prices[0].Sources)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for aggregated price")
+	}
+
+	// Cancellation stops every source and closes the output channel
+	cancel()
+
+	select {
+	case _, ok := <-priceCh:
+		assert.False(t, ok, "channel should be closed after cancellation")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for aggregator to stop")
+	}
+}
@@ -1,14 +1,20 @@
 // Package pricefeed provides price data structures and interfaces for the DecTek service
 package pricefeed
 
+import (
+	"context"
+)
+
 // Price represents a token's price data
 type Price struct {
-	Symbol string  // Token symbol (e.g., "BTC", "ETH")
-	USD    float64 // Price in USD
+	Symbol  string   `json:"symbol"`            // Token symbol (e.g., "BTC", "ETH")
+	USD     Decimal  `json:"usd"`               // Exact price in USD
+	Sources []string `json:"sources,omitempty"` // Names of the sources that contributed to this price
 }
 
 // PriceProvider defines the interface for services that provide price updates
 type PriceProvider interface {
-	// UpdatePriceFromApi continuously fetches and sends price updates to the provided channel
-	UpdatePriceFromApi(priceCh chan<- []Price)
+	// UpdatePriceFromApi continuously fetches and sends price updates to the provided channel.
+	// It returns once ctx is canceled and closes priceCh before returning.
+	UpdatePriceFromApi(ctx context.Context, priceCh chan<- []Price)
 }
@@ -8,7 +8,7 @@ import (
 // PriceFeed defines the interface for blockchain price feed operations
 type PriceFeed interface {
 	// OnChainPrices returns the current prices stored on the blockchain
-	OnChainPrices() map[string]float64
+	OnChainPrices() map[string]Decimal
 
 	// ListenOnChainPriceUpdate listens for price updates on the blockchain and
 	// sends them to the provided channel
@@ -0,0 +1,275 @@
+package pricefeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// divisionScale is the number of fractional digits kept when a division does not terminate
+const divisionScale = 18
+
+// maxParseScale bounds the decimal exponent accepted by ParseDecimal, larger ones make arithmetic
+// on the result allocate powers of ten of that many digits
+const maxParseScale = 100
+
+// Decimal is an exact fixed-point decimal number. It is stored as an unscaled integer
+// and a number of fractional digits, and encoded in JSON as a string to avoid float rounding.
+// The zero value is 0.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+// NewDecimal returns unscaled * 10^-scale
+func NewDecimal(unscaled *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{unscaled: new(big.Int).Mul(unscaled, pow10(uint(-scale)))}
+	}
+
+	return Decimal{unscaled: new(big.Int).Set(unscaled), scale: scale}
+}
+
+// DecimalFromUnits converts an on-chain integer with the given number of decimals to a Decimal
+func DecimalFromUnits(units *big.Int, decimals uint8) Decimal {
+	return NewDecimal(units, int32(decimals))
+}
+
+// ParseDecimal parses a plain decimal string such as "30000.12" or "-0.5". Exponents are accepted
+// as long as the value has at most maxParseScale fractional digits or trailing zeros.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+
+	mantissa, exponent := s, int64(0)
+
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+
+		mantissa, exponent = s[:i], exp
+	}
+
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+
+	digits := strings.TrimPrefix(strings.TrimPrefix(intPart, "-"), "+") + fracPart
+	if digits == "" || strings.ContainsAny(fracPart, "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	scale := int64(len(fracPart)) - exponent
+	if scale > maxParseScale || scale < -maxParseScale {
+		return Decimal{}, fmt.Errorf("decimal %q is out of range", s)
+	}
+
+	return NewDecimal(unscaled, int32(scale)), nil
+}
+
+// MustDecimal parses s and panics if it is not a valid decimal. Intended for constants and tests.
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// DecimalFromFloat converts a float64 through its shortest decimal representation,
+// so 0.1 becomes exactly 0.1 instead of the nearest binary fraction
+func DecimalFromFloat(f float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Decimal{}
+	}
+
+	return d
+}
+
+// DecimalFromInt returns v as a Decimal
+func DecimalFromInt(v int64) Decimal {
+	return Decimal{unscaled: big.NewInt(v)}
+}
+
+// int returns the unscaled value, treating the zero value as 0
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+
+	return d.unscaled
+}
+
+// rescale returns the unscaled value of d expressed with scale digits, scale must be >= d.scale
+func (d Decimal) rescale(scale int32) *big.Int {
+	return new(big.Int).Mul(d.int(), pow10(uint(scale-d.scale)))
+}
+
+// align returns the unscaled values of d and o at a common scale
+func (d Decimal) align(o Decimal) (*big.Int, *big.Int, int32) {
+	scale := max(d.scale, o.scale)
+
+	return d.rescale(scale), o.rescale(scale), scale
+}
+
+// Add returns d + o
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b, scale := d.align(o)
+
+	return Decimal{unscaled: a.Add(a, b), scale: scale}
+}
+
+// Sub returns d - o
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b, scale := d.align(o)
+
+	return Decimal{unscaled: a.Sub(a, b), scale: scale}
+}
+
+// Mul returns d * o
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Div returns d / o, rounded half away from zero when the result does not terminate
+// within divisionScale fractional digits. It panics if o is zero.
+func (d Decimal) Div(o Decimal) Decimal {
+	r := new(big.Rat).Quo(d.Rat(), o.Rat())
+
+	return decimalFromRat(r, max(divisionScale, d.scale))
+}
+
+// Round returns d rounded half away from zero to the given number of fractional digits
+func (d Decimal) Round(decimals uint8) Decimal {
+	if d.scale <= int32(decimals) {
+		return d
+	}
+
+	return decimalFromRat(d.Rat(), int32(decimals))
+}
+
+// ToUnits converts d to an on-chain integer with the given number of decimals,
+// rounding half away from zero
+func (d Decimal) ToUnits(decimals uint8) *big.Int {
+	return d.Round(decimals).rescale(int32(decimals))
+}
+
+// Cmp compares d and o and returns -1, 0 or +1
+func (d Decimal) Cmp(o Decimal) int {
+	a, b, _ := d.align(o)
+
+	return a.Cmp(b)
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	return Decimal{unscaled: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Rat returns d as a big.Rat
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.int(), pow10(uint(d.scale)))
+}
+
+// Float64 returns the nearest float64 to d, for logging and ratio checks only
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+
+	return f
+}
+
+// String formats d as a plain decimal without trailing zeros, e.g. "30000.12"
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+
+	if d.scale <= 0 {
+		return sign + digits
+	}
+
+	if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	point := len(digits) - int(d.scale)
+	intPart, fracPart := digits[:point], strings.TrimRight(digits[point:], "0")
+
+	if fracPart == "" {
+		return sign + intPart
+	}
+
+	return sign + intPart + "." + fracPart
+}
+
+// MarshalJSON encodes d as a JSON string
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts both JSON strings and JSON numbers, null leaves d unchanged
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	s := strings.Trim(string(data), `"`)
+
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
+
+// decimalFromRat rounds r half away from zero to scale fractional digits
+func decimalFromRat(r *big.Rat, scale int32) Decimal {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(uint(scale))))
+
+	return Decimal{unscaled: roundRat(scaled), scale: scale}
+}
+
+// pow10 returns 10^n
+func pow10(n uint) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundRat rounds r to the nearest integer, half away from zero
+func roundRat(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	q, m := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+
+	if m.Lsh(m, 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+
+	return q
+}
@@ -0,0 +1,112 @@
+package pricefeed
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "30000.12", want: "30000.12"},
+		{in: "0.00001234", want: "0.00001234"},
+		{in: "-0.5", want: "-0.5"},
+		{in: ".5", want: "0.5"},
+		{in: "100.000", want: "100"},
+		{in: "1.5e3", want: "1500"},
+		{in: "1234e-8", want: "0.00001234"},
+		{in: "", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "1.-2", wantErr: true},
+		{in: "1e100", want: "1" + strings.Repeat("0", 100)},
+		{in: "1e-1000000000", wantErr: true},
+		{in: "1e2147483647", wantErr: true},
+		{in: "0." + strings.Repeat("0", 100) + "1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDecimal(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := MustDecimal("100.25")
+	b := MustDecimal("0.75")
+
+	assert.Equal(t, "101", a.Add(b).String())
+	assert.Equal(t, "99.5", a.Sub(b).String())
+	assert.Equal(t, "75.1875", a.Mul(b).String())
+	assert.Equal(t, "50.125", a.Div(DecimalFromInt(2)).String())
+	assert.Equal(t, "0.333333333333333333", DecimalFromInt(1).Div(DecimalFromInt(3)).String())
+	assert.Equal(t, 1, a.Cmp(b))
+	assert.Equal(t, 0, MustDecimal("1.50").Cmp(MustDecimal("1.5")))
+	assert.True(t, Decimal{}.IsZero())
+	assert.Equal(t, "0", Decimal{}.String())
+}
+
+func TestDecimalFromFloat(t *testing.T) {
+	assert.Equal(t, "0.1", DecimalFromFloat(0.1).String())
+	assert.Equal(t, "30000.12", DecimalFromFloat(30000.12).String())
+	assert.Equal(t, 30000.12, DecimalFromFloat(30000.12).Float64())
+}
+
+func TestDecimalUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    string
+		decimals uint8
+		want     string
+	}{
+		{name: "cents", price: "30000.12", decimals: 2, want: "3000012"},
+		{name: "rounds half up", price: "0.125", decimals: 2, want: "13"},
+		{name: "rounds negative away from zero", price: "-0.125", decimals: 2, want: "-13"},
+		{name: "sub cent price", price: "0.00001234", decimals: 8, want: "1234"},
+		{name: "eighteen decimals", price: "2000.123456789012345678", decimals: 18, want: "2000123456789012345678"},
+		{name: "zero decimals", price: "99.5", decimals: 0, want: "100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units := MustDecimal(tt.price).ToUnits(tt.decimals)
+			assert.Equal(t, tt.want, units.String())
+		})
+	}
+
+	units, _ := new(big.Int).SetString("2000123456789012345678", 10)
+	assert.Equal(t, "2000.123456789012345678", DecimalFromUnits(units, 18).String())
+	assert.Equal(t, "30000.12", DecimalFromUnits(big.NewInt(3000012), 2).String())
+}
+
+func TestDecimalJSON(t *testing.T) {
+	data, err := json.Marshal(Price{Symbol: "bitcoin", USD: MustDecimal("30000.12")})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"symbol":"bitcoin","usd":"30000.12"}`, string(data))
+
+	var out struct {
+		Quoted Decimal `json:"quoted"`
+		Number Decimal `json:"number"`
+	}
+
+	err = json.Unmarshal([]byte(`{"quoted":"0.00001234","number":30000.12}`), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.00001234", out.Quoted.String())
+	assert.Equal(t, "30000.12", out.Number.String())
+}
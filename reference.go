@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/sljivkov/dectek/apis"
+	"github.com/sljivkov/dectek/chains"
+	"github.com/sljivkov/dectek/config"
+)
+
+// newReference builds the sanity band reference from the ordered REFERENCES list.
+// It returns nil when the list is "none", disabling the sanity check. Chainlink only requires a feed
+// for every token when no reference follows it.
+func newReference(ctx context.Context, cfg config.Config, client *ethclient.Client,
+	twap *chains.UniswapTWAP,
+) (chains.ReferencePricer, error) {
+	var references []chains.ReferencePricer
+
+	names := strings.Split(cfg.References, ",")
+
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+
+		switch name {
+		case "none":
+			if len(names) > 1 {
+				return nil, fmt.Errorf("reference %q cannot be combined with others", name)
+			}
+
+			return nil, nil //nolint:nilnil // a nil reference disables the sanity check
+		case "chainlink":
+			chainlink, err := chains.NewChainlinkReference(ctx, cfg, client, i < len(names)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			references = append(references, chainlink)
+		case "uniswap":
+			if twap == nil {
+				return nil, fmt.Errorf("uniswap reference requires UNISWAP_POOLS")
+			}
+
+			references = append(references, twap)
+		// Reference APIs get their own provider so they do not share state with the polled sources
+		case "coingecko":
+			references = append(references, chains.NewAPIReference(name, apis.NewCoinGecko(cfg)))
+		case "binance":
+			references = append(references, chains.NewAPIReference(name, apis.NewBinance(cfg)))
+		case "coinbase":
+			references = append(references, chains.NewAPIReference(name, apis.NewCoinbase(cfg)))
+		case "kraken":
+			references = append(references, chains.NewAPIReference(name, apis.NewKraken(cfg)))
+		default:
+			return nil, fmt.Errorf("unknown reference %q", name)
+		}
+	}
+
+	if len(references) == 1 {
+		return references[0], nil
+	}
+
+	return chains.NewFallbackReference(references...), nil
+}